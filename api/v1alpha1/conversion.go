@@ -61,3 +61,11 @@ func Convert_v1alpha1_InClusterIPPoolSpec_To_v1alpha2_InClusterIPPoolSpec(in *In
 func Convert_v1alpha2_InClusterIPPoolSpec_To_v1alpha1_InClusterIPPoolSpec(in *v1alpha2.InClusterIPPoolSpec, out *InClusterIPPoolSpec, s conversion.Scope) error {
 	return autoConvert_v1alpha2_InClusterIPPoolSpec_To_v1alpha1_InClusterIPPoolSpec(in, out, s)
 }
+
+func Convert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(in *v1alpha2.InClusterIPPoolStatus, out *InClusterIPPoolStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(in, out, s)
+}
+
+func Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(in *v1alpha2.InClusterIPPoolStatusIPAddresses, out *InClusterIPPoolStatusIPAddresses, s conversion.Scope) error {
+	return autoConvert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(in, out, s)
+}
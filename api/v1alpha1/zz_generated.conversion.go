@@ -80,21 +80,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha2.InClusterIPPoolStatus)(nil), (*InClusterIPPoolStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(a.(*v1alpha2.InClusterIPPoolStatus), b.(*InClusterIPPoolStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*InClusterIPPoolStatusIPAddresses)(nil), (*v1alpha2.InClusterIPPoolStatusIPAddresses)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_InClusterIPPoolStatusIPAddresses_To_v1alpha2_InClusterIPPoolStatusIPAddresses(a.(*InClusterIPPoolStatusIPAddresses), b.(*v1alpha2.InClusterIPPoolStatusIPAddresses), scope)
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha2.InClusterIPPoolStatusIPAddresses)(nil), (*InClusterIPPoolStatusIPAddresses)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(a.(*v1alpha2.InClusterIPPoolStatusIPAddresses), b.(*InClusterIPPoolStatusIPAddresses), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddConversionFunc((*InClusterIPPoolSpec)(nil), (*v1alpha2.InClusterIPPoolSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_InClusterIPPoolSpec_To_v1alpha2_InClusterIPPoolSpec(a.(*InClusterIPPoolSpec), b.(*v1alpha2.InClusterIPPoolSpec), scope)
 	}); err != nil {
@@ -105,6 +95,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha2.InClusterIPPoolStatus)(nil), (*InClusterIPPoolStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(a.(*v1alpha2.InClusterIPPoolStatus), b.(*InClusterIPPoolStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha2.InClusterIPPoolStatusIPAddresses)(nil), (*InClusterIPPoolStatusIPAddresses)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(a.(*v1alpha2.InClusterIPPoolStatusIPAddresses), b.(*InClusterIPPoolStatusIPAddresses), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -270,13 +270,36 @@ func autoConvert_v1alpha2_InClusterIPPoolSpec_To_v1alpha1_InClusterIPPoolSpec(in
 	out.Addresses = *(*[]string)(unsafe.Pointer(&in.Addresses))
 	out.Prefix = in.Prefix
 	out.Gateway = in.Gateway
+	// WARNING: in.GatewayPolicy requires manual conversion: does not exist in peer-type
 	// WARNING: in.AllocateReservedIPAddresses requires manual conversion: does not exist in peer-type
+	// WARNING: in.ReservedAddressPolicy requires manual conversion: does not exist in peer-type
 	// WARNING: in.ExcludedAddresses requires manual conversion: does not exist in peer-type
+	// WARNING: in.NetworkMetadata requires manual conversion: does not exist in peer-type
+	// WARNING: in.DeletionPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.AllowedNamespaces requires manual conversion: does not exist in peer-type
+	// WARNING: in.Quotas requires manual conversion: does not exist in peer-type
+	// WARNING: in.ClusterSubPools requires manual conversion: does not exist in peer-type
+	// WARNING: in.OwnerCluster requires manual conversion: does not exist in peer-type
+	// WARNING: in.AllowOverlap requires manual conversion: does not exist in peer-type
+	// WARNING: in.ParentRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.RateLimits requires manual conversion: does not exist in peer-type
+	// WARNING: in.AddressSubsets requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailureDomainLabel requires manual conversion: does not exist in peer-type
+	// WARNING: in.PurposeRanges requires manual conversion: does not exist in peer-type
+	// WARNING: in.ReservedAddresses requires manual conversion: does not exist in peer-type
 	return nil
 }
 
 func autoConvert_v1alpha1_InClusterIPPoolStatus_To_v1alpha2_InClusterIPPoolStatus(in *InClusterIPPoolStatus, out *v1alpha2.InClusterIPPoolStatus, s conversion.Scope) error {
-	out.Addresses = (*v1alpha2.InClusterIPPoolStatusIPAddresses)(unsafe.Pointer(in.Addresses))
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = new(v1alpha2.InClusterIPPoolStatusIPAddresses)
+		if err := Convert_v1alpha1_InClusterIPPoolStatusIPAddresses_To_v1alpha2_InClusterIPPoolStatusIPAddresses(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Addresses = nil
+	}
 	return nil
 }
 
@@ -286,15 +309,24 @@ func Convert_v1alpha1_InClusterIPPoolStatus_To_v1alpha2_InClusterIPPoolStatus(in
 }
 
 func autoConvert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(in *v1alpha2.InClusterIPPoolStatus, out *InClusterIPPoolStatus, s conversion.Scope) error {
-	out.Addresses = (*InClusterIPPoolStatusIPAddresses)(unsafe.Pointer(in.Addresses))
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = new(InClusterIPPoolStatusIPAddresses)
+		if err := Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Addresses = nil
+	}
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
+	// WARNING: in.QuotaUsage requires manual conversion: does not exist in peer-type
+	// WARNING: in.SubPools requires manual conversion: does not exist in peer-type
+	// WARNING: in.ChildPools requires manual conversion: does not exist in peer-type
+	// WARNING: in.ChildAddresses requires manual conversion: does not exist in peer-type
+	// WARNING: in.PurposeUsage requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus is an autogenerated conversion function.
-func Convert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(in *v1alpha2.InClusterIPPoolStatus, out *InClusterIPPoolStatus, s conversion.Scope) error {
-	return autoConvert_v1alpha2_InClusterIPPoolStatus_To_v1alpha1_InClusterIPPoolStatus(in, out, s)
-}
-
 func autoConvert_v1alpha1_InClusterIPPoolStatusIPAddresses_To_v1alpha2_InClusterIPPoolStatusIPAddresses(in *InClusterIPPoolStatusIPAddresses, out *v1alpha2.InClusterIPPoolStatusIPAddresses, s conversion.Scope) error {
 	out.Total = in.Total
 	out.Free = in.Free
@@ -313,10 +345,7 @@ func autoConvert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InCluster
 	out.Free = in.Free
 	out.Used = in.Used
 	out.OutOfRange = in.OutOfRange
+	// WARNING: in.Reserved requires manual conversion: does not exist in peer-type
+	// WARNING: in.Retiring requires manual conversion: does not exist in peer-type
 	return nil
 }
-
-// Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses is an autogenerated conversion function.
-func Convert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(in *v1alpha2.InClusterIPPoolStatusIPAddresses, out *InClusterIPPoolStatusIPAddresses, s conversion.Scope) error {
-	return autoConvert_v1alpha2_InClusterIPPoolStatusIPAddresses_To_v1alpha1_InClusterIPPoolStatusIPAddresses(in, out, s)
-}
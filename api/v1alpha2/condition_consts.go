@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+const (
+	// AddressesClaimedCondition reports whether all IPAddresses allocated from
+	// the pool are still backed by an IPAddressClaim.
+	AddressesClaimedCondition clusterv1.ConditionType = "AddressesClaimed"
+
+	// OrphanedAddressesReason is used when one or more IPAddresses of the pool
+	// have been found orphaned by the garbage collector.
+	OrphanedAddressesReason = "OrphanedAddresses"
+
+	// PoolDeletingReason is used on IPAddressClaims whose pool is being
+	// deleted, either because their address was released by a pool deleted
+	// with the Cascade deletion policy or because no address can be allocated
+	// from it anymore.
+	PoolDeletingReason = "PoolDeleting"
+
+	// NamespaceNotAllowedReason is used on IPAddressClaims whose namespace is
+	// not allowed to allocate addresses from the referenced pool.
+	NamespaceNotAllowedReason = "NamespaceNotAllowed"
+
+	// QuotaExceededReason is used on IPAddressClaims that cannot be fulfilled
+	// because their namespace or Cluster has exhausted a quota of the pool.
+	QuotaExceededReason = "QuotaExceeded"
+
+	// RateLimitedReason is used on IPAddressClaims whose allocation is
+	// delayed because a rate limit of the pool is exceeded.
+	RateLimitedReason = "RateLimited"
+
+	// FailureDomainExhaustedReason is used on IPAddressClaims that cannot be
+	// fulfilled because all addresses of the subsets of the pool matching
+	// their failure domain are allocated, or the pool has no such subsets.
+	FailureDomainExhaustedReason = "FailureDomainExhausted"
+
+	// PurposeExhaustedReason is used on IPAddressClaims that cannot be
+	// fulfilled because all addresses of the purpose ranges of the pool
+	// matching their purpose are allocated, or the pool has no such ranges.
+	PurposeExhaustedReason = "PurposeExhausted"
+
+	// GatewayUnavailableReason is used on IPAddressClaims that request the
+	// gateway of a pool which has no gateway, or whose gateway is already
+	// allocated.
+	GatewayUnavailableReason = "GatewayUnavailable"
+
+	// WaitingForOlderClaimsReason is used on IPAddressClaims whose allocation
+	// is delayed until older pending claims of the pool are fulfilled.
+	WaitingForOlderClaimsReason = "WaitingForOlderClaims"
+)
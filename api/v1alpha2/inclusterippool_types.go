@@ -17,35 +17,441 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// DNSServersAnnotation is set on IPAddresses allocated from a pool with
+	// network metadata and contains a comma separated list of DNS servers.
+	DNSServersAnnotation = "ipam.cluster.x-k8s.io/dns-servers"
+
+	// SearchDomainsAnnotation is set on IPAddresses allocated from a pool with
+	// network metadata and contains a comma separated list of search domains.
+	SearchDomainsAnnotation = "ipam.cluster.x-k8s.io/search-domains"
+
+	// MTUAnnotation is set on IPAddresses allocated from a pool with network
+	// metadata and contains the MTU of the network.
+	MTUAnnotation = "ipam.cluster.x-k8s.io/mtu"
+
+	// VLANIDAnnotation is set on IPAddresses allocated from a pool with network
+	// metadata and contains the VLAN ID of the network.
+	VLANIDAnnotation = "ipam.cluster.x-k8s.io/vlan-id"
+
+	// RoutesAnnotation is set on IPAddresses allocated from a pool with network
+	// metadata and contains the JSON encoded list of static routes.
+	RoutesAnnotation = "ipam.cluster.x-k8s.io/routes"
+
+	// NetmaskAnnotation is set on IPv4 IPAddresses allocated from a pool with
+	// network metadata and contains the prefix in dotted decimal notation.
+	NetmaskAnnotation = "ipam.cluster.x-k8s.io/netmask"
+
+	// OrphanedSinceAnnotation is set on IPAddresses whose claim no longer
+	// exists, or whose claim belongs to a Cluster that no longer exists. It
+	// contains the RFC 3339 timestamp at which the address was first found to
+	// be orphaned.
+	OrphanedSinceAnnotation = "ipam.cluster.x-k8s.io/orphaned-since"
+
+	// SubPoolOfLabel is set on InClusterIPPools that were carved out of a
+	// GlobalInClusterIPPool for a Cluster and contains the name of the
+	// GlobalInClusterIPPool.
+	SubPoolOfLabel = "ipam.cluster.x-k8s.io/sub-pool-of"
+
+	// PausedByOwnerCluster is the value of the cluster.x-k8s.io/paused
+	// annotation when it was set on an InClusterIPPool because its owner
+	// Cluster is paused. The annotation is only removed again when the Cluster
+	// is unpaused if it has this value.
+	PausedByOwnerCluster = "owner-cluster"
+
+	// DefaultPoolAnnotation marks an InClusterIPPool as the default pool of
+	// its namespace, or a GlobalInClusterIPPool as the cluster-wide default
+	// pool, when set to "true". IPAddressClaims without a poolRef are assigned
+	// the default pool of their namespace, or the cluster-wide default pool if
+	// their namespace has none.
+	DefaultPoolAnnotation = "ipam.cluster.x-k8s.io/is-default-pool"
+
+	// GatewayClaimAnnotation is set to "true" on an IPAddressClaim to allocate
+	// the gateway of its pool, e.g. to the virtual router that holds it. The
+	// gateway is allocated to at most one claim.
+	GatewayClaimAnnotation = "ipam.cluster.x-k8s.io/claim-gateway"
+
+	// PurposeLabel is set on IPAddressClaims, as label or annotation, to
+	// request an address of the purpose ranges of a pool with this purpose.
+	PurposeLabel = "ipam.cluster.x-k8s.io/purpose"
 )
 
 // InClusterIPPoolSpec defines the desired state of InClusterIPPool.
+// +kubebuilder:validation:XValidation:rule="!has(self.excludedAddresses) || size(self.addresses) == 0 || self.excludedAddresses.all(a, a.contains(':') == self.addresses[0].contains(':'))",message="addresses and excluded addresses are of mixed IP families"
+// +kubebuilder:validation:XValidation:rule="!has(self.gateway) || size(self.addresses) == 0 || !isIP(self.gateway) || ip(self.gateway).family() == (self.addresses[0].contains(':') ? 6 : 4)",message="provided gateway and addresses are of mixed IP families"
+// +kubebuilder:validation:XValidation:rule="size(self.addresses) == 0 || (has(self.prefix) && self.prefix >= 1 && self.prefix <= (self.addresses[0].contains(':') ? 128 : 32))",message="prefix must be between 1 and 32 for IPv4 and between 1 and 128 for IPv6"
 type InClusterIPPoolSpec struct {
 	// Addresses is a list of IP addresses that can be assigned. This set of
-	// addresses can be non-contiguous.
+	// addresses can be non-contiguous. Each entry is a single IP, a CIDR
+	// such as 10.0.0.0/24 or 10.0.0.0/255.255.255.0, a range such as
+	// 10.0.0.10-10.0.0.50 or 10.0.0.10-50, or offsets within a CIDR such as
+	// 10.0.0.0/24[10-200].
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1024
+	// +kubebuilder:validation:items:MaxLength=128
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isIP(a) || isCIDR(a) || (a.matches('^[0-9a-fA-F:.]+-[0-9a-fA-F:.]+$') && isIP(a.split('-')[0])) || (a.matches('^[0-9.]+/[0-9.]+$') && isIP(a.split('/')[0]) && isIP(a.split('/')[1])) || (a.endsWith(']') && size(a.split('[')) == 2 && a.split('[')[1].matches('^[0-9]+(-[0-9]+)?]$') && (isCIDR(a.split('[')[0]) || (a.split('[')[0].matches('^[0-9.]+/[0-9.]+$') && isIP(a.split('[')[0].split('/')[0]) && isIP(a.split('[')[0].split('/')[1])))))",message="provided address is not a valid IP, range, nor CIDR"
+	// +kubebuilder:validation:XValidation:rule="self.all(a, a.contains(':')) || self.all(a, !a.contains(':'))",message="provided addresses are of mixed IP families"
 	Addresses []string `json:"addresses"`
 
-	// Prefix is the network prefix to use.
+	// Prefix is the network prefix to use. It is derived from Addresses when
+	// they consist of a single CIDR.
 	// +kubebuilder:validation:Maximum=128
+	// +optional
 	Prefix int `json:"prefix"`
 
 	// Gateway
+	// +kubebuilder:validation:MaxLength=45
+	// +kubebuilder:validation:XValidation:rule="self == '' || isIP(self)",message="gateway must be a valid IP"
 	// +optional
 	Gateway string `json:"gateway,omitempty"`
 
+	// GatewayPolicy derives the Gateway from the inferred subnet when no
+	// Gateway is set. FirstUsable uses the first address after the network
+	// address, LastUsable the last address before the broadcast address.
+	// +kubebuilder:validation:Enum=FirstUsable;LastUsable
+	// +optional
+	GatewayPolicy GatewayPolicy `json:"gatewayPolicy,omitempty"`
+
 	// AllocateReservedIPAddresses causes the provider to allocate the network
 	// address (the first address in the inferred subnet) and broadcast address
 	// (the last address in the inferred subnet) when IPv4. The provider will
 	// allocate the anycast address address (the first address in the inferred
-	// subnet) when IPv6.
+	// subnet) when IPv6. Point-to-point subnets (/31 and /127) and single host
+	// subnets (/32 and /128) have no reserved addresses, all of their addresses
+	// are always allocated.
 	// +optional
 	AllocateReservedIPAddresses bool `json:"allocateReservedIPAddresses,omitempty"`
 
+	// ReservedAddressPolicy defines which addresses of the inferred subnet are
+	// never allocated. AllocateReservedIPAddresses is a shorthand for a policy
+	// that does not reserve the subnet boundaries.
+	// +optional
+	ReservedAddressPolicy *ReservedAddressPolicy `json:"reservedAddressPolicy,omitempty"`
+
 	// ExcludedAddresses is a list of IP addresses, which will be excluded from
-	// the set of assignable IP addresses.
+	// the set of assignable IP addresses. Allocated addresses that are excluded
+	// are retired: they remain valid for their current holder but are not
+	// allocated again once released.
+	// +kubebuilder:validation:MaxItems=1024
+	// +kubebuilder:validation:items:MaxLength=128
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isIP(a) || isCIDR(a) || (a.matches('^[0-9a-fA-F:.]+-[0-9a-fA-F:.]+$') && isIP(a.split('-')[0])) || (a.matches('^[0-9.]+/[0-9.]+$') && isIP(a.split('/')[0]) && isIP(a.split('/')[1])) || (a.endsWith(']') && size(a.split('[')) == 2 && a.split('[')[1].matches('^[0-9]+(-[0-9]+)?]$') && (isCIDR(a.split('[')[0]) || (a.split('[')[0].matches('^[0-9.]+/[0-9.]+$') && isIP(a.split('[')[0].split('/')[0]) && isIP(a.split('[')[0].split('/')[1])))))",message="provided address is not a valid IP, range, nor CIDR"
+	// +kubebuilder:validation:XValidation:rule="self.all(a, a.contains(':')) || self.all(a, !a.contains(':'))",message="provided addresses are of mixed IP families"
 	// +optional
 	ExcludedAddresses []string `json:"excludedAddresses,omitempty"`
+
+	// NetworkMetadata contains additional information about the network. It
+	// is copied onto every IPAddress allocated from the pool as annotations,
+	// so that infrastructure providers can consume it.
+	// +optional
+	NetworkMetadata *NetworkMetadata `json:"networkMetadata,omitempty"`
+
+	// DeletionPolicy defines what happens to the IPAddresses allocated from
+	// the pool when the pool is deleted. Block prevents the deletion of the
+	// pool while it has IPAddresses allocated. Cascade releases all
+	// IPAddresses of the pool and marks their IPAddressClaims as failed.
+	// Defaults to Block.
+	// +kubebuilder:validation:Enum=Block;Cascade
+	// +kubebuilder:default=Block
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// AllowedNamespaces restricts the namespaces from which IPAddressClaims
+	// may allocate addresses from the pool. Claims from all namespaces are
+	// allowed when it is not set. Only supported on GlobalInClusterIPPools.
+	// +optional
+	AllowedNamespaces *AllowedNamespaces `json:"allowedNamespaces,omitempty"`
+
+	// Quotas limit the number of addresses that can be allocated from the
+	// pool per namespace or per Cluster.
+	// +optional
+	Quotas []Quota `json:"quotas,omitempty"`
+
+	// ClusterSubPools configures the creation of an InClusterIPPool for every
+	// Cluster matching a selector. The addresses of each of these pools are a
+	// contiguous block carved out of the free addresses of this pool, and are
+	// returned to this pool when the Cluster is deleted. Only supported on
+	// GlobalInClusterIPPools.
+	// +optional
+	ClusterSubPools *ClusterSubPools `json:"clusterSubPools,omitempty"`
+
+	// OwnerCluster binds the pool to the lifecycle of a Cluster in the same
+	// namespace. The pool is owned by the Cluster, paused while the Cluster
+	// is paused, and deleted once the Cluster is gone and all addresses of
+	// the pool have been released. Only supported on InClusterIPPools.
+	// +optional
+	OwnerCluster *corev1.LocalObjectReference `json:"ownerCluster,omitempty"`
+
+	// AllowOverlap allows the addresses of the pool to overlap with the
+	// addresses of other InClusterIPPools and GlobalInClusterIPPools. Pools
+	// that overlap are rejected unless one of them allows it. Addresses that
+	// are allocated from an overlapping pool are never allocated twice.
+	// +optional
+	AllowOverlap bool `json:"allowOverlap,omitempty"`
+
+	// ParentRef makes the pool a child of another pool. The addresses of a
+	// child pool must be within the addresses of its parent and must not
+	// overlap with the addresses of its siblings. They are not allocated from
+	// the parent itself, and the parent reports the usage of all of its
+	// descendants. InClusterIPPools can have an InClusterIPPool in the same
+	// namespace or a GlobalInClusterIPPool as parent, GlobalInClusterIPPools
+	// only a GlobalInClusterIPPool.
+	// +optional
+	ParentRef *PoolReference `json:"parentRef,omitempty"`
+
+	// RateLimits limit how many addresses can be allocated from the pool per
+	// minute. IPAddressClaims exceeding a limit are retried once the limit
+	// allows another allocation.
+	// +optional
+	RateLimits *RateLimits `json:"rateLimits,omitempty"`
+
+	// AddressSubsets assign labels, such as the zone or rack of the network
+	// segment they belong to, to subsets of the addresses of the pool.
+	// +optional
+	AddressSubsets []AddressSubset `json:"addressSubsets,omitempty"`
+
+	// FailureDomainLabel is the label of the address subsets that contains
+	// their failure domain. IPAddressClaims that have this label, or that are
+	// owned by a Machine with a failure domain, are only allocated addresses
+	// of the subsets with the same failure domain. Defaults to
+	// topology.kubernetes.io/zone.
+	// +optional
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+
+	// PurposeRanges reserve named ranges of the addresses of the pool for a
+	// purpose, such as virtual IPs or control plane nodes. IPAddressClaims
+	// with the ipam.cluster.x-k8s.io/purpose label or annotation are only
+	// allocated addresses of the ranges with that purpose. All other claims
+	// are only allocated addresses outside of the purpose ranges.
+	// +optional
+	PurposeRanges []PurposeRange `json:"purposeRanges,omitempty"`
+
+	// ReservedAddresses are addresses of the pool that are used outside of
+	// Cluster API, e.g. by switches or firewalls. Unlike ExcludedAddresses
+	// they are never allocated but count as used.
+	// +optional
+	ReservedAddresses []ReservedAddress `json:"reservedAddresses,omitempty"`
+}
+
+// ReservedAddress is a list of addresses of a pool reserved for an owner
+// outside of Cluster API.
+type ReservedAddress struct {
+	// Addresses is a list of IP addresses, ranges or CIDRs within the
+	// addresses of the pool.
+	Addresses []string `json:"addresses"`
+
+	// Owner of the addresses, e.g. the team or device using them.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// Description of what the addresses are used for.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// ReservedAddressPolicy defines which addresses of the subnet of a pool are
+// never allocated.
+type ReservedAddressPolicy struct {
+	// SubnetBoundaries reserves the network and broadcast address of IPv4
+	// subnets and the subnet-router anycast address of IPv6 subnets. Defaults
+	// to true, unless AllocateReservedIPAddresses is set.
+	// +optional
+	SubnetBoundaries *bool `json:"subnetBoundaries,omitempty"`
+
+	// SubnetAnycast reserves the subnet anycast addresses of RFC 2526, the
+	// highest 128 addresses of each /64, in IPv6 pools.
+	// +optional
+	SubnetAnycast bool `json:"subnetAnycast,omitempty"`
+
+	// OctetBoundaries reserves the addresses ending in .0 or .255 in IPv4
+	// subnets larger than /24, which some appliances reject.
+	// +optional
+	OctetBoundaries bool `json:"octetBoundaries,omitempty"`
+
+	// FirstAddresses is the number of addresses following the network address
+	// of the subnet that are reserved, e.g. for routers using HSRP or VRRP.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=256
+	// +optional
+	FirstAddresses int `json:"firstAddresses,omitempty"`
+}
+
+// PurposeRange is a named range of the addresses of a pool reserved for a
+// purpose.
+type PurposeRange struct {
+	// Name of the range. It must be unique within the pool.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Purpose the addresses of the range are reserved for.
+	// +kubebuilder:validation:MinLength=1
+	Purpose string `json:"purpose"`
+
+	// Addresses is a list of IP addresses, ranges or CIDRs within the
+	// addresses of the pool.
+	Addresses []string `json:"addresses"`
+}
+
+// AddressSubset is a subset of the addresses of a pool with labels.
+type AddressSubset struct {
+	// Addresses is a list of IP addresses, ranges or CIDRs within the
+	// addresses of the pool.
+	Addresses []string `json:"addresses"`
+
+	// Labels of the addresses.
+	Labels map[string]string `json:"labels"`
+}
+
+// RateLimits limit the number of addresses allocated per minute.
+type RateLimits struct {
+	// AllocationsPerMinute is the maximum number of addresses allocated from
+	// the pool per minute.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	AllocationsPerMinute int `json:"allocationsPerMinute,omitempty"`
+
+	// AllocationsPerMinutePerCluster is the maximum number of addresses
+	// allocated from the pool per minute to each Cluster, as referenced by
+	// the cluster.x-k8s.io/cluster-name label of their IPAddressClaim.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	AllocationsPerMinutePerCluster int `json:"allocationsPerMinutePerCluster,omitempty"`
+}
+
+// PoolReference references an InClusterIPPool in the same namespace or a
+// GlobalInClusterIPPool.
+type PoolReference struct {
+	// Kind is the kind of the pool.
+	// +kubebuilder:validation:Enum=InClusterIPPool;GlobalInClusterIPPool
+	Kind string `json:"kind"`
+
+	// Name is the name of the pool.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ClusterSubPools defines which Clusters get a sub-pool and how large it is.
+type ClusterSubPools struct {
+	// ClusterSelector selects the Clusters that get a sub-pool by their labels.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Prefix is the prefix length of the block of addresses carved out for
+	// each sub-pool, e.g. 28 for 16 IPv4 addresses. It must not be smaller
+	// than the prefix of the pool.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=128
+	Prefix int `json:"prefix"`
+}
+
+// AllowedNamespaces selects the namespaces that may use a pool. A namespace is
+// allowed when it is listed in Names or matches Selector.
+type AllowedNamespaces struct {
+	// Selector selects the allowed namespaces by their labels.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Names is a list of allowed namespaces.
+	// +optional
+	Names []string `json:"names,omitempty"`
+}
+
+// DeletionPolicy defines how a pool handles its IPAddresses when it is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyBlock prevents the deletion of a pool while it has
+	// IPAddresses allocated.
+	DeletionPolicyBlock DeletionPolicy = "Block"
+
+	// DeletionPolicyCascade releases all IPAddresses of a pool when it is
+	// deleted.
+	DeletionPolicyCascade DeletionPolicy = "Cascade"
+)
+
+// GatewayPolicy defines which address of the subnet of a pool is its gateway.
+type GatewayPolicy string
+
+const (
+	// GatewayPolicyFirstUsable uses the first usable address of the subnet.
+	GatewayPolicyFirstUsable GatewayPolicy = "FirstUsable"
+
+	// GatewayPolicyLastUsable uses the last usable address of the subnet.
+	GatewayPolicyLastUsable GatewayPolicy = "LastUsable"
+)
+
+// NetworkMetadata describes the network a pool belongs to.
+type NetworkMetadata struct {
+	// DNSServers is a list of DNS server addresses.
+	// +optional
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// SearchDomains is a list of DNS search domains.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// MTU is the maximum transmission unit of the network.
+	// +kubebuilder:validation:Minimum=576
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	MTU int `json:"mtu,omitempty"`
+
+	// VLANID is the ID of the VLAN the network is attached to.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLANID int `json:"vlanID,omitempty"`
+
+	// Routes is a list of static routes.
+	// +optional
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// Route is a static route.
+type Route struct {
+	// To is the destination network in CIDR notation.
+	To string `json:"to"`
+
+	// Via is the address of the next hop.
+	Via string `json:"via"`
+
+	// Metric is the metric of the route.
+	// +optional
+	Metric int `json:"metric,omitempty"`
+}
+
+// QuotaKey defines what a quota counts addresses by.
+type QuotaKey string
+
+const (
+	// QuotaKeyNamespace counts addresses per namespace of their IPAddressClaim.
+	QuotaKeyNamespace QuotaKey = "Namespace"
+
+	// QuotaKeyCluster counts addresses per Cluster, as referenced by the
+	// cluster.x-k8s.io/cluster-name label of their IPAddressClaim. Clusters
+	// are identified as <namespace>/<name>.
+	QuotaKeyCluster QuotaKey = "Cluster"
+)
+
+// Quota limits the number of addresses per namespace or per Cluster.
+type Quota struct {
+	// Key defines whether the quota applies per namespace or per Cluster.
+	// +kubebuilder:validation:Enum=Namespace;Cluster
+	Key QuotaKey `json:"key"`
+
+	// Values restricts the quota to the listed namespaces, or Clusters in the
+	// form <namespace>/<name>. The quota applies to every namespace or Cluster
+	// individually when it is empty.
+	// +optional
+	Values []string `json:"values,omitempty"`
+
+	// Limit is the maximum number of addresses each namespace or Cluster may
+	// allocate from the pool.
+	// +kubebuilder:validation:Minimum=0
+	Limit int `json:"limit"`
 }
 
 // InClusterIPPoolStatus defines the observed state of InClusterIPPool.
@@ -53,6 +459,99 @@ type InClusterIPPoolStatus struct {
 	// Addresses reports the count of total, free, and used IPs in the pool.
 	// +optional
 	Addresses *InClusterIPPoolStatusIPAddresses `json:"ipAddresses,omitempty"`
+
+	// Conditions defines current service state of the pool.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// QuotaUsage reports the number of addresses used by each namespace or
+	// Cluster that is limited by a quota.
+	// +optional
+	QuotaUsage []QuotaUsage `json:"quotaUsage,omitempty"`
+
+	// SubPools lists the InClusterIPPools carved out of the pool for
+	// Clusters. Their addresses are not allocated from the pool itself.
+	// +optional
+	SubPools []SubPool `json:"subPools,omitempty"`
+
+	// ChildPools lists the pools that have the pool as parent. Their
+	// addresses are not allocated from the pool itself.
+	// +optional
+	ChildPools []ChildPool `json:"childPools,omitempty"`
+
+	// ChildAddresses reports the count of total, free, and used IPs of all
+	// child pools and their descendants.
+	// +optional
+	ChildAddresses *InClusterIPPoolStatusIPAddresses `json:"childIPAddresses,omitempty"`
+
+	// PurposeUsage reports the count of total, free, and used IPs of the
+	// purpose ranges of each purpose.
+	// +optional
+	PurposeUsage []PurposeUsage `json:"purposeUsage,omitempty"`
+}
+
+// PurposeUsage reports the usage of the purpose ranges of a purpose.
+type PurposeUsage struct {
+	// Purpose of the ranges.
+	Purpose string `json:"purpose"`
+
+	// Total is the number of IPs of the ranges.
+	Total int `json:"total"`
+
+	// Used is the number of allocated IPs of the ranges.
+	Used int `json:"used"`
+
+	// Free is the number of unallocated IPs of the ranges.
+	Free int `json:"free"`
+}
+
+// ChildPool is a pool that has another pool as parent.
+type ChildPool struct {
+	// Kind is the kind of the child pool.
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the child pool. It is empty for
+	// GlobalInClusterIPPools.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the child pool.
+	Name string `json:"name"`
+
+	// Addresses is the comma separated list of the addresses of the child pool.
+	Addresses string `json:"addresses"`
+}
+
+// SubPool is an InClusterIPPool carved out of a pool for a Cluster.
+type SubPool struct {
+	// Namespace is the namespace of the InClusterIPPool and its Cluster.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the InClusterIPPool.
+	Name string `json:"name"`
+
+	// ClusterName is the name of the Cluster the InClusterIPPool was created for.
+	ClusterName string `json:"clusterName"`
+
+	// Addresses is the block of addresses assigned to the InClusterIPPool in
+	// CIDR notation.
+	Addresses string `json:"addresses"`
+}
+
+// QuotaUsage reports the usage of a quota by a namespace or Cluster.
+type QuotaUsage struct {
+	// Key is the key of the quota.
+	Key QuotaKey `json:"key"`
+
+	// Value is the namespace, or the Cluster in the form <namespace>/<name>.
+	Value string `json:"value"`
+
+	// Used is the number of addresses allocated to the namespace or Cluster.
+	Used int `json:"used"`
+
+	// Limit is the maximum number of addresses the namespace or Cluster may
+	// allocate.
+	Limit int `json:"limit"`
 }
 
 // InClusterIPPoolStatusIPAddresses contains the count of total, free, and used IPs in a pool.
@@ -73,6 +572,20 @@ type InClusterIPPoolStatusIPAddresses struct {
 	// contained within spec.Addresses.
 	// Counts greater than int can contain will report as math.MaxInt.
 	OutOfRange int `json:"outOfRange"`
+
+	// Reserved is the count of IPs in the pool reserved by
+	// spec.reservedAddresses. They are included in the count of used IPs.
+	// Counts greater than int can contain will report as math.MaxInt.
+	// +optional
+	Reserved int `json:"reserved,omitempty"`
+
+	// Retiring is the count of allocated IPs that were added to
+	// spec.excludedAddresses. They remain valid for their current holder but
+	// are not allocated again once released. They are not included in the
+	// count of used IPs.
+	// Counts greater than int can contain will report as math.MaxInt.
+	// +optional
+	Retiring int `json:"retiring,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -159,3 +672,23 @@ func (p *GlobalInClusterIPPool) PoolSpec() *InClusterIPPoolSpec {
 func (p *GlobalInClusterIPPool) PoolStatus() *InClusterIPPoolStatus {
 	return &p.Status
 }
+
+// GetConditions returns the set of conditions for this object.
+func (p *InClusterIPPool) GetConditions() clusterv1.Conditions {
+	return p.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (p *InClusterIPPool) SetConditions(conditions clusterv1.Conditions) {
+	p.Status.Conditions = conditions
+}
+
+// GetConditions returns the set of conditions for this object.
+func (p *GlobalInClusterIPPool) GetConditions() clusterv1.Conditions {
+	return p.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (p *GlobalInClusterIPPool) SetConditions(conditions clusterv1.Conditions) {
+	p.Status.Conditions = conditions
+}
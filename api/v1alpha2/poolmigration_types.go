@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MigrationLabel is set on the IPAddressClaims created by a PoolMigration
+	// to pre-allocate the new addresses and contains the name of the
+	// PoolMigration.
+	MigrationLabel = "ipam.cluster.x-k8s.io/migration"
+
+	// MigrationAddressAnnotation is set on IPAddressClaims that are migrated
+	// by a PoolMigration, and on their current IPAddress, once the new
+	// address has been allocated. It contains the name of the new IPAddress.
+	MigrationAddressAnnotation = "ipam.cluster.x-k8s.io/migration-address"
+
+	// MigrationAcknowledgedAnnotation is set on a migrated IPAddressClaim to
+	// "true" once its consumer has switched to the new address. The claim is
+	// then switched to the new IPAddress and its old IPAddress is released.
+	MigrationAcknowledgedAnnotation = "ipam.cluster.x-k8s.io/migration-acknowledged"
+
+	// MigrationCompletedCondition reports whether all IPAddressClaims of a
+	// PoolMigration have been switched to the new pool.
+	MigrationCompletedCondition clusterv1.ConditionType = "MigrationCompleted"
+
+	// WaitingForAcknowledgementReason is used when new addresses have been
+	// allocated, but not all claims have acknowledged the switch yet.
+	WaitingForAcknowledgementReason = "WaitingForAcknowledgement"
+
+	// AllocatingAddressesReason is used while new addresses are allocated
+	// from the new pool.
+	AllocatingAddressesReason = "AllocatingAddresses"
+)
+
+// PoolMigrationSpec defines the desired state of PoolMigration.
+type PoolMigrationSpec struct {
+	// From is the pool the IPAddressClaims in the namespace of the
+	// PoolMigration are migrated from.
+	From PoolReference `json:"from"`
+
+	// To is the pool new addresses are allocated from.
+	To PoolReference `json:"to"`
+}
+
+// PoolMigrationStatus defines the observed state of PoolMigration.
+type PoolMigrationStatus struct {
+	// Claims is the number of IPAddressClaims that are migrated.
+	// +optional
+	Claims int `json:"claims"`
+
+	// Allocated is the number of IPAddressClaims whose new address has been
+	// allocated.
+	// +optional
+	Allocated int `json:"allocated"`
+
+	// Completed is the number of IPAddressClaims that have been switched to
+	// their new address.
+	// +optional
+	Completed int `json:"completed"`
+
+	// Conditions of the PoolMigration.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:categories=cluster-api
+// +kubebuilder:printcolumn:name="From",type="string",JSONPath=".spec.from.name",description="Pool the claims are migrated from"
+// +kubebuilder:printcolumn:name="To",type="string",JSONPath=".spec.to.name",description="Pool the claims are migrated to"
+// +kubebuilder:printcolumn:name="Claims",type="integer",JSONPath=".status.claims",description="Count of migrated claims"
+// +kubebuilder:printcolumn:name="Allocated",type="integer",JSONPath=".status.allocated",description="Count of claims with a new address"
+// +kubebuilder:printcolumn:name="Completed",type="integer",JSONPath=".status.completed",description="Count of claims switched to the new address"
+
+// PoolMigration migrates the IPAddressClaims of a pool in its namespace to
+// another pool. It allocates a new address for every claim and switches the
+// claim to it once the claim acknowledges the switch.
+type PoolMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PoolMigrationSpec   `json:"spec,omitempty"`
+	Status PoolMigrationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PoolMigrationList contains a list of PoolMigration.
+type PoolMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PoolMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PoolMigration{}, &PoolMigrationList{})
+}
+
+// GetConditions returns the set of conditions for this object.
+func (m *PoolMigration) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *PoolMigration) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
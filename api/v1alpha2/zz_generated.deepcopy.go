@@ -1,5 +1,4 @@
 //go:build !ignore_autogenerated
-// +build !ignore_autogenerated
 
 /*
 Copyright The Kubernetes Authors.
@@ -22,9 +21,95 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressSubset) DeepCopyInto(out *AddressSubset) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressSubset.
+func (in *AddressSubset) DeepCopy() *AddressSubset {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressSubset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedNamespaces) DeepCopyInto(out *AllowedNamespaces) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedNamespaces.
+func (in *AllowedNamespaces) DeepCopy() *AllowedNamespaces {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedNamespaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChildPool) DeepCopyInto(out *ChildPool) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChildPool.
+func (in *ChildPool) DeepCopy() *ChildPool {
+	if in == nil {
+		return nil
+	}
+	out := new(ChildPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSubPools) DeepCopyInto(out *ClusterSubPools) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSubPools.
+func (in *ClusterSubPools) DeepCopy() *ClusterSubPools {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSubPools)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalInClusterIPPool) DeepCopyInto(out *GlobalInClusterIPPool) {
 	*out = *in
@@ -151,11 +236,74 @@ func (in *InClusterIPPoolSpec) DeepCopyInto(out *InClusterIPPoolSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReservedAddressPolicy != nil {
+		in, out := &in.ReservedAddressPolicy, &out.ReservedAddressPolicy
+		*out = new(ReservedAddressPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ExcludedAddresses != nil {
 		in, out := &in.ExcludedAddresses, &out.ExcludedAddresses
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NetworkMetadata != nil {
+		in, out := &in.NetworkMetadata, &out.NetworkMetadata
+		*out = new(NetworkMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = new(AllowedNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = make([]Quota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterSubPools != nil {
+		in, out := &in.ClusterSubPools, &out.ClusterSubPools
+		*out = new(ClusterSubPools)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnerCluster != nil {
+		in, out := &in.OwnerCluster, &out.OwnerCluster
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ParentRef != nil {
+		in, out := &in.ParentRef, &out.ParentRef
+		*out = new(PoolReference)
+		**out = **in
+	}
+	if in.RateLimits != nil {
+		in, out := &in.RateLimits, &out.RateLimits
+		*out = new(RateLimits)
+		**out = **in
+	}
+	if in.AddressSubsets != nil {
+		in, out := &in.AddressSubsets, &out.AddressSubsets
+		*out = make([]AddressSubset, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PurposeRanges != nil {
+		in, out := &in.PurposeRanges, &out.PurposeRanges
+		*out = make([]PurposeRange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReservedAddresses != nil {
+		in, out := &in.ReservedAddresses, &out.ReservedAddresses
+		*out = make([]ReservedAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InClusterIPPoolSpec.
@@ -176,6 +324,38 @@ func (in *InClusterIPPoolStatus) DeepCopyInto(out *InClusterIPPoolStatus) {
 		*out = new(InClusterIPPoolStatusIPAddresses)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.QuotaUsage != nil {
+		in, out := &in.QuotaUsage, &out.QuotaUsage
+		*out = make([]QuotaUsage, len(*in))
+		copy(*out, *in)
+	}
+	if in.SubPools != nil {
+		in, out := &in.SubPools, &out.SubPools
+		*out = make([]SubPool, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChildPools != nil {
+		in, out := &in.ChildPools, &out.ChildPools
+		*out = make([]ChildPool, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChildAddresses != nil {
+		in, out := &in.ChildAddresses, &out.ChildAddresses
+		*out = new(InClusterIPPoolStatusIPAddresses)
+		**out = **in
+	}
+	if in.PurposeUsage != nil {
+		in, out := &in.PurposeUsage, &out.PurposeUsage
+		*out = make([]PurposeUsage, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InClusterIPPoolStatus.
@@ -202,3 +382,301 @@ func (in *InClusterIPPoolStatusIPAddresses) DeepCopy() *InClusterIPPoolStatusIPA
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkMetadata) DeepCopyInto(out *NetworkMetadata) {
+	*out = *in
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]Route, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkMetadata.
+func (in *NetworkMetadata) DeepCopy() *NetworkMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMigration) DeepCopyInto(out *PoolMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMigration.
+func (in *PoolMigration) DeepCopy() *PoolMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PoolMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMigrationList) DeepCopyInto(out *PoolMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PoolMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMigrationList.
+func (in *PoolMigrationList) DeepCopy() *PoolMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PoolMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMigrationSpec) DeepCopyInto(out *PoolMigrationSpec) {
+	*out = *in
+	out.From = in.From
+	out.To = in.To
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMigrationSpec.
+func (in *PoolMigrationSpec) DeepCopy() *PoolMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMigrationStatus) DeepCopyInto(out *PoolMigrationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMigrationStatus.
+func (in *PoolMigrationStatus) DeepCopy() *PoolMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolReference) DeepCopyInto(out *PoolReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolReference.
+func (in *PoolReference) DeepCopy() *PoolReference {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurposeRange) DeepCopyInto(out *PurposeRange) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurposeRange.
+func (in *PurposeRange) DeepCopy() *PurposeRange {
+	if in == nil {
+		return nil
+	}
+	out := new(PurposeRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PurposeUsage) DeepCopyInto(out *PurposeUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PurposeUsage.
+func (in *PurposeUsage) DeepCopy() *PurposeUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(PurposeUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Quota) DeepCopyInto(out *Quota) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Quota.
+func (in *Quota) DeepCopy() *Quota {
+	if in == nil {
+		return nil
+	}
+	out := new(Quota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaUsage) DeepCopyInto(out *QuotaUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaUsage.
+func (in *QuotaUsage) DeepCopy() *QuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimits) DeepCopyInto(out *RateLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimits.
+func (in *RateLimits) DeepCopy() *RateLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservedAddress) DeepCopyInto(out *ReservedAddress) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservedAddress.
+func (in *ReservedAddress) DeepCopy() *ReservedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservedAddressPolicy) DeepCopyInto(out *ReservedAddressPolicy) {
+	*out = *in
+	if in.SubnetBoundaries != nil {
+		in, out := &in.SubnetBoundaries, &out.SubnetBoundaries
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservedAddressPolicy.
+func (in *ReservedAddressPolicy) DeepCopy() *ReservedAddressPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservedAddressPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route) DeepCopyInto(out *Route) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
+	if in == nil {
+		return nil
+	}
+	out := new(Route)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubPool) DeepCopyInto(out *SubPool) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubPool.
+func (in *SubPool) DeepCopy() *SubPool {
+	if in == nil {
+		return nil
+	}
+	out := new(SubPool)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,15 +19,24 @@ package controllers
 import (
 	"context"
 	"net/netip"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
+	"go4.org/netipx"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -36,7 +45,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/metrics"
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/poolutil"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/ipamutil"
 	pooltypes "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/types"
 )
 
@@ -51,7 +62,8 @@ const (
 // InClusterIPPoolReconciler reconciles a InClusterIPPool object.
 type InClusterIPPoolReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -61,9 +73,58 @@ func (r *InClusterIPPoolReconciler) SetupWithManager(_ context.Context, mgr ctrl
 		Watches(
 			&ipamv1.IPAddress{},
 			handler.EnqueueRequestsFromMapFunc(r.ipAddressToInClusterIPPool)).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterToInClusterIPPools)).
+		Watches(
+			&v1alpha2.InClusterIPPool{},
+			handler.EnqueueRequestsFromMapFunc(childPoolToParent(inClusterIPPoolKind))).
 		Complete(r)
 }
 
+// childPoolToParent returns a map function that enqueues the parent of a pool
+// if it is of the given kind.
+func childPoolToParent(parentKind string) handler.MapFunc {
+	return func(_ context.Context, clientObj client.Object) []reconcile.Request {
+		pool, ok := clientObj.(pooltypes.GenericInClusterPool)
+		if !ok {
+			return nil
+		}
+
+		ref := pool.PoolSpec().ParentRef
+		if ref == nil || ref.Kind != parentKind {
+			return nil
+		}
+
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: ref.Name}}
+		if parentKind == inClusterIPPoolKind {
+			request.Namespace = pool.GetNamespace()
+		}
+		return []reconcile.Request{request}
+	}
+}
+
+func (r *InClusterIPPoolReconciler) clusterToInClusterIPPools(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	pools := &v1alpha2.InClusterIPPoolList{}
+	if err := r.Client.List(ctx, pools, client.InNamespace(clientObj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, pool := range pools.Items {
+		if pool.Spec.OwnerCluster == nil || pool.Spec.OwnerCluster.Name != clientObj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: pool.Namespace,
+				Name:      pool.Name,
+			},
+		})
+	}
+	return requests
+}
+
 func (r *InClusterIPPoolReconciler) ipAddressToInClusterIPPool(_ context.Context, clientObj client.Object) []reconcile.Request {
 	ipAddress, ok := clientObj.(*ipamv1.IPAddress)
 	if !ok {
@@ -87,7 +148,8 @@ func (r *InClusterIPPoolReconciler) ipAddressToInClusterIPPool(_ context.Context
 // GlobalInClusterIPPoolReconciler reconciles a GlobalInClusterIPPool object.
 type GlobalInClusterIPPoolReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -97,9 +159,26 @@ func (r *GlobalInClusterIPPoolReconciler) SetupWithManager(_ context.Context, mg
 		Watches(
 			&ipamv1.IPAddress{},
 			handler.EnqueueRequestsFromMapFunc(r.ipAddressToGlobalInClusterIPPool)).
+		Watches(
+			&v1alpha2.InClusterIPPool{},
+			handler.EnqueueRequestsFromMapFunc(r.inClusterIPPoolToGlobalInClusterIPPool)).
+		Watches(
+			&v1alpha2.GlobalInClusterIPPool{},
+			handler.EnqueueRequestsFromMapFunc(childPoolToParent(globalInClusterIPPoolKind))).
 		Complete(r)
 }
 
+func (r *GlobalInClusterIPPoolReconciler) inClusterIPPoolToGlobalInClusterIPPool(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	requests := childPoolToParent(globalInClusterIPPoolKind)(ctx, clientObj)
+
+	if parentName, ok := clientObj.GetLabels()[v1alpha2.SubPoolOfLabel]; ok {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: parentName},
+		})
+	}
+	return requests
+}
+
 func (r *GlobalInClusterIPPoolReconciler) ipAddressToGlobalInClusterIPPool(_ context.Context, clientObj client.Object) []reconcile.Request {
 	ipAddress, ok := clientObj.(*ipamv1.IPAddress)
 	if !ok {
@@ -123,6 +202,7 @@ func (r *GlobalInClusterIPPoolReconciler) ipAddressToGlobalInClusterIPPool(_ con
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -135,9 +215,104 @@ func (r *InClusterIPPoolReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		if !apierrors.IsNotFound(err) {
 			return ctrl.Result{}, errors.Wrap(err, "failed to fetch InClusterIPPool")
 		}
+		metrics.DeletePool(inClusterIPPoolKind, req.Namespace, req.Name)
 		return ctrl.Result{}, nil
 	}
-	return genericReconcile(ctx, r.Client, pool)
+
+	if pool.Spec.OwnerCluster != nil && pool.DeletionTimestamp.IsZero() {
+		deleted, err := r.reconcileOwnerCluster(ctx, pool)
+		if err != nil || deleted {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return genericReconcile(ctx, r.Client, r.Recorder, pool)
+}
+
+// reconcileOwnerCluster adds the owner reference of the owner Cluster to the
+// pool and pauses the pool together with the Cluster. Once the Cluster is gone
+// and all addresses have been released, the pool is deleted, in which case
+// true is returned.
+func (r *InClusterIPPoolReconciler) reconcileOwnerCluster(ctx context.Context, pool *v1alpha2.InClusterIPPool) (bool, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	patchHelper, err := patch.NewHelper(pool, r.Client)
+	if err != nil {
+		return false, err
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: pool.Namespace, Name: pool.Spec.OwnerCluster.Name}, cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, errors.Wrap(err, "failed to fetch owner Cluster")
+		}
+
+		// Without an owner reference the Cluster has not been created yet.
+		if !hasClusterOwnerReference(pool, pool.Spec.OwnerCluster.Name) {
+			log.Info("Owner Cluster of pool not found", "cluster", pool.Spec.OwnerCluster.Name)
+			return false, nil
+		}
+
+		if pool.Annotations[clusterv1.PausedAnnotation] == v1alpha2.PausedByOwnerCluster {
+			delete(pool.Annotations, clusterv1.PausedAnnotation)
+			if err := patchHelper.Patch(ctx, pool); err != nil {
+				return false, err
+			}
+		}
+
+		addressesInUse, err := poolutil.ListAddressesInUse(ctx, r.Client, pool.Namespace, corev1.TypedLocalObjectReference{
+			APIGroup: ptr.To(v1alpha2.GroupVersion.Group),
+			Kind:     inClusterIPPoolKind,
+			Name:     pool.Name,
+		})
+		if err != nil {
+			return false, errors.Wrap(err, "failed to list addresses")
+		}
+		if len(addressesInUse) > 0 {
+			log.Info("Owner Cluster of pool is gone, waiting for addresses to be released", "cluster", pool.Spec.OwnerCluster.Name, "addresses", len(addressesInUse))
+			return false, nil
+		}
+
+		log.Info("Owner Cluster of pool is gone, deleting pool", "cluster", pool.Spec.OwnerCluster.Name)
+		if err := r.Client.Delete(ctx, pool); err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.Wrap(err, "failed to delete pool")
+		}
+		return true, nil
+	}
+
+	if !hasClusterOwnerReference(pool, cluster.Name) {
+		pool.OwnerReferences = append(pool.OwnerReferences, metav1.OwnerReference{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+			Name:       cluster.Name,
+			UID:        cluster.UID,
+		})
+	}
+
+	paused := annotations.IsPaused(cluster, cluster)
+	switch {
+	case paused && !annotations.HasPaused(pool):
+		log.Info("Pausing pool together with its owner Cluster", "cluster", cluster.Name)
+		annotations.AddAnnotations(pool, map[string]string{clusterv1.PausedAnnotation: v1alpha2.PausedByOwnerCluster})
+	case !paused && pool.Annotations[clusterv1.PausedAnnotation] == v1alpha2.PausedByOwnerCluster:
+		log.Info("Unpausing pool together with its owner Cluster", "cluster", cluster.Name)
+		delete(pool.Annotations, clusterv1.PausedAnnotation)
+	}
+
+	return false, patchHelper.Patch(ctx, pool)
+}
+
+func hasClusterOwnerReference(obj metav1.Object, clusterName string) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		if gv.Group == clusterv1.GroupVersion.Group && ref.Kind == "Cluster" && ref.Name == clusterName {
+			return true
+		}
+	}
+	return false
 }
 
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools,verbs=get;list;watch;create;update;patch;delete
@@ -155,12 +330,15 @@ func (r *GlobalInClusterIPPoolReconciler) Reconcile(ctx context.Context, req ctr
 		if !apierrors.IsNotFound(err) {
 			return ctrl.Result{}, errors.Wrap(err, "failed to fetch GlobalInClusterIPPool")
 		}
+		// Requests mapped from IPAddresses carry the namespace of the address,
+		// the metrics of global pools are recorded without one.
+		metrics.DeletePool(globalInClusterIPPoolKind, "", req.Name)
 		return ctrl.Result{}, nil
 	}
-	return genericReconcile(ctx, r.Client, pool)
+	return genericReconcile(ctx, r.Client, r.Recorder, pool)
 }
 
-func genericReconcile(ctx context.Context, c client.Client, pool pooltypes.GenericInClusterPool) (_ ctrl.Result, reterr error) {
+func genericReconcile(ctx context.Context, c client.Client, recorder record.EventRecorder, pool pooltypes.GenericInClusterPool) (_ ctrl.Result, reterr error) {
 	log := ctrl.LoggerFrom(ctx)
 
 	patchHelper, err := patch.NewHelper(pool, c)
@@ -187,11 +365,31 @@ func genericReconcile(ctx context.Context, c client.Client, pool pooltypes.Gener
 
 	inUseCount := len(addressesInUse)
 
+	orphanedCount := 0
+	for _, address := range addressesInUse {
+		if _, ok := address.Annotations[v1alpha2.OrphanedSinceAnnotation]; ok {
+			orphanedCount++
+		}
+	}
+	metrics.OrphanedAddresses.With(metrics.PoolLabels(poolTypeRef.Kind, pool.GetNamespace(), pool.GetName())).Set(float64(orphanedCount))
+	if orphanedCount > 0 {
+		conditions.MarkFalse(pool, v1alpha2.AddressesClaimedCondition, v1alpha2.OrphanedAddressesReason, clusterv1.ConditionSeverityWarning,
+			"%d of %d addresses are orphaned", orphanedCount, inUseCount)
+	} else {
+		conditions.MarkTrue(pool, v1alpha2.AddressesClaimedCondition)
+	}
+
 	if !controllerutil.ContainsFinalizer(pool, ProtectPoolFinalizer) {
 		controllerutil.AddFinalizer(pool, ProtectPoolFinalizer)
 	}
 
 	if !pool.GetDeletionTimestamp().IsZero() {
+		if inUseCount > 0 && pool.PoolSpec().DeletionPolicy == v1alpha2.DeletionPolicyCascade {
+			if err := releaseAddresses(ctx, c, recorder, pool, addressesInUse); err != nil {
+				return ctrl.Result{}, err
+			}
+			inUseCount = 0
+		}
 		if inUseCount == 0 {
 			controllerutil.RemoveFinalizer(pool, ProtectPoolFinalizer)
 		}
@@ -215,20 +413,209 @@ func genericReconcile(ctx context.Context, c client.Client, pool pooltypes.Gener
 		}
 	}
 
-	free := poolCount - inUseCount
+	pool.PoolStatus().SubPools = nil
+	if poolTypeRef.Kind == globalInClusterIPPoolKind {
+		subPools, err := poolutil.ListSubPools(ctx, c, pool.GetName())
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to list sub-pools")
+		}
+		subPoolIPSet, err := poolutil.SubPoolsToIPSet(subPools)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to build sub-pool ip set")
+		}
+
+		// Addresses carved out for sub-pools can no longer be allocated from this pool.
+		builder := &netipx.IPSetBuilder{}
+		builder.AddSet(poolIPSet)
+		builder.Intersect(subPoolIPSet)
+		carvedIPSet, err := builder.IPSet()
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to build sub-pool ip set")
+		}
+		poolCount -= poolutil.IPSetCount(carvedIPSet)
+
+		for _, subPool := range subPools {
+			pool.PoolStatus().SubPools = append(pool.PoolStatus().SubPools, v1alpha2.SubPool{
+				Namespace:   subPool.Namespace,
+				Name:        subPool.Name,
+				ClusterName: subPool.Labels[clusterv1.ClusterNameLabel],
+				Addresses:   strings.Join(subPool.Spec.Addresses, ","),
+			})
+		}
+		sort.Slice(pool.PoolStatus().SubPools, func(i, j int) bool {
+			a, b := pool.PoolStatus().SubPools[i], pool.PoolStatus().SubPools[j]
+			return a.Namespace < b.Namespace || a.Namespace == b.Namespace && a.Name < b.Name
+		})
+	}
+
+	childPools, err := poolutil.ListChildPools(ctx, c, pool)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list child pools")
+	}
+	childPoolIPSet, err := poolutil.ChildPoolsToIPSet(childPools)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build child pool ip set")
+	}
+	// Addresses of child pools are counted by the child pools.
+	builder := &netipx.IPSetBuilder{}
+	builder.AddSet(poolIPSet)
+	builder.Intersect(childPoolIPSet)
+	childIPSet, err := builder.IPSet()
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build child pool ip set")
+	}
+	poolCount -= poolutil.IPSetCount(childIPSet)
+	pool.PoolStatus().ChildPools, pool.PoolStatus().ChildAddresses = childPoolsStatus(childPools)
+
+	// Reserved addresses count as used unless they are allocated anyway.
+	reservedIPSet, err := poolutil.ReservedAddressesToIPSet(pool.PoolSpec(), poolIPSet)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build reserved ip set")
+	}
+	reservedCount := poolutil.IPSetCount(reservedIPSet)
+
+	// Allocated addresses that were excluded from the pool are retired, they
+	// are neither used nor out of range.
+	retiringIPSet, err := poolutil.RetiringIPSet(pool.PoolSpec(), addressesInUse)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build retiring ip set")
+	}
+	retiringCount := poolutil.IPSetCount(retiringIPSet)
+
+	// An allocated gateway is not part of the pool, it is neither used nor out
+	// of range.
+	gatewayIPSet, err := poolutil.GatewayIPSet(pool.PoolSpec())
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to parse pool gateway")
+	}
+	gatewayCount := poolutil.AddressesInIPSetCount(addressesInUse, gatewayIPSet)
+
+	usedCount := inUseCount - retiringCount - gatewayCount + reservedCount - poolutil.AddressesInIPSetCount(addressesInUse, reservedIPSet)
+
+	free := poolCount - usedCount
 	outOfRangeIPSet, err := poolutil.AddressesOutOfRangeIPSet(addressesInUse, poolIPSet)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "failed to build out of range ip set")
 	}
+	outOfRangeBuilder := &netipx.IPSetBuilder{}
+	outOfRangeBuilder.AddSet(outOfRangeIPSet)
+	outOfRangeBuilder.RemoveSet(retiringIPSet)
+	outOfRangeBuilder.RemoveSet(gatewayIPSet)
+	if outOfRangeIPSet, err = outOfRangeBuilder.IPSet(); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build out of range ip set")
+	}
 
 	pool.PoolStatus().Addresses = &v1alpha2.InClusterIPPoolStatusIPAddresses{
 		Total:      poolCount,
-		Used:       inUseCount,
+		Used:       usedCount,
 		Free:       free,
 		OutOfRange: poolutil.IPSetCount(outOfRangeIPSet),
+		Reserved:   reservedCount,
+		Retiring:   retiringCount,
+	}
+	metrics.SetPoolAddresses(poolTypeRef.Kind, pool.GetNamespace(), pool.GetName(), pool.PoolStatus().Addresses)
+
+	pool.PoolStatus().QuotaUsage = nil
+	if quotas := pool.PoolSpec().Quotas; len(quotas) > 0 {
+		clusterNames, err := poolutil.ClaimClusterNames(ctx, c, pool.GetNamespace(), poolTypeRef)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to list claims")
+		}
+		pool.PoolStatus().QuotaUsage = poolutil.QuotaUsage(quotas, addressesInUse, clusterNames)
+	}
+
+	pool.PoolStatus().PurposeUsage, err = poolutil.PurposeUsage(pool.PoolSpec(), poolIPSet, addressesInUse)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to build purpose range ip set")
 	}
 
 	log.Info("Updating pool with usage info", "statusAddresses", pool.PoolStatus().Addresses)
 
 	return ctrl.Result{}, nil
 }
+
+// childPoolsStatus lists the child pools of a pool and sums up the address
+// counts of the child pools and their descendants.
+func childPoolsStatus(childPools []pooltypes.GenericInClusterPool) ([]v1alpha2.ChildPool, *v1alpha2.InClusterIPPoolStatusIPAddresses) {
+	if len(childPools) == 0 {
+		return nil, nil
+	}
+
+	children := make([]v1alpha2.ChildPool, 0, len(childPools))
+	addresses := &v1alpha2.InClusterIPPoolStatusIPAddresses{}
+	for _, child := range childPools {
+		children = append(children, v1alpha2.ChildPool{
+			Kind:      poolutil.PoolKind(child),
+			Namespace: child.GetNamespace(),
+			Name:      child.GetName(),
+			Addresses: strings.Join(child.PoolSpec().Addresses, ","),
+		})
+		for _, counts := range []*v1alpha2.InClusterIPPoolStatusIPAddresses{child.PoolStatus().Addresses, child.PoolStatus().ChildAddresses} {
+			if counts == nil {
+				continue
+			}
+			addresses.Total += counts.Total
+			addresses.Free += counts.Free
+			addresses.Used += counts.Used
+			addresses.OutOfRange += counts.OutOfRange
+			addresses.Reserved += counts.Reserved
+			addresses.Retiring += counts.Retiring
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		a, b := children[i], children[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Namespace < b.Namespace || a.Namespace == b.Namespace && a.Name < b.Name
+	})
+	return children, addresses
+}
+
+// releaseAddresses releases all addresses of a pool that is deleted with the
+// Cascade deletion policy and marks their claims as failed.
+func releaseAddresses(ctx context.Context, c client.Client, recorder record.EventRecorder, pool pooltypes.GenericInClusterPool, addresses []ipamv1.IPAddress) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	for i := range addresses {
+		address := &addresses[i]
+
+		claim := &ipamv1.IPAddressClaim{}
+		claimKey := types.NamespacedName{Namespace: address.Namespace, Name: address.Spec.ClaimRef.Name}
+		if err := c.Get(ctx, claimKey, claim); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrap(err, "failed to fetch IPAddressClaim")
+			}
+			claim = nil
+		}
+
+		if claim != nil && claim.DeletionTimestamp.IsZero() {
+			claimPatchHelper, err := patch.NewHelper(claim, c)
+			if err != nil {
+				return err
+			}
+			conditions.MarkFalse(claim, clusterv1.ReadyCondition, v1alpha2.PoolDeletingReason, clusterv1.ConditionSeverityError,
+				"address %s was released because pool %s was deleted", address.Spec.Address, pool.GetName())
+			if err := claimPatchHelper.Patch(ctx, claim); err != nil {
+				return errors.Wrap(err, "failed to patch IPAddressClaim")
+			}
+			recorder.Eventf(claim, corev1.EventTypeWarning, v1alpha2.PoolDeletingReason,
+				"Address %s was released because pool %s was deleted", address.Spec.Address, pool.GetName())
+		}
+
+		if controllerutil.RemoveFinalizer(address, ipamutil.ProtectAddressFinalizer) {
+			if err := c.Update(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrap(err, "failed to remove address finalizer")
+			}
+		}
+		if err := c.Delete(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to delete address")
+		}
+
+		log.Info("Released address of deleted pool", "address", address.Name, "ip", address.Spec.Address)
+		recorder.Eventf(pool, corev1.EventTypeNormal, "AddressReleased",
+			"Released address %s of IPAddressClaim %s/%s", address.Spec.Address, address.Namespace, address.Spec.ClaimRef.Name)
+	}
+
+	return nil
+}
@@ -23,8 +23,12 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
@@ -176,6 +180,85 @@ var _ = Describe("IP Pool Reconciler", func() {
 			Entry("GlobalInClusterIPPool when removing broadcast address",
 				"GlobalInClusterIPPool", []string{"10.0.0.251-10.0.0.255"}, "10.0.0.1", []string{"10.0.0.251-10.0.0.254"}, 5, 1),
 		)
+
+		It("counts reserved addresses as used and does not allocate them", func() {
+			genericPool = &v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: testPool, Namespace: namespace},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.10-10.0.0.20"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+					ReservedAddresses: []v1alpha2.ReservedAddress{
+						{Addresses: []string{"10.0.0.10-10.0.0.11"}, Owner: "network", Description: "switches"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), genericPool)).To(Succeed())
+
+			claim := newClaim("test0", namespace, "InClusterIPPool", genericPool.GetName())
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			createdClaimNames = append(createdClaimNames, claim.Name)
+
+			Eventually(findAddress("test0", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.12")))
+			Eventually(Object(genericPool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Status.Addresses", Equal(&v1alpha2.InClusterIPPoolStatusIPAddresses{
+					Total:    11,
+					Used:     3,
+					Free:     8,
+					Reserved: 2,
+				})))
+		})
+
+		It("retires allocated addresses that are excluded", func() {
+			genericPool = &v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: testPool, Namespace: namespace},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.10-10.0.0.20"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), genericPool)).To(Succeed())
+
+			claim := newClaim("test0", namespace, "InClusterIPPool", genericPool.GetName())
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			createdClaimNames = append(createdClaimNames, claim.Name)
+			Eventually(findAddress("test0", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.10")))
+
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(genericPool), genericPool)).To(Succeed())
+			genericPool.PoolSpec().ExcludedAddresses = []string{"10.0.0.10"}
+			Expect(k8sClient.Update(context.Background(), genericPool)).To(Succeed())
+
+			Eventually(Object(genericPool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Status.Addresses", Equal(&v1alpha2.InClusterIPPoolStatusIPAddresses{
+					Total:    10,
+					Used:     0,
+					Free:     10,
+					Retiring: 1,
+				})))
+			Consistently(findAddress("test0", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.10")))
+
+			deleteClaim("test0", namespace)
+			createdClaimNames = nil
+			Eventually(Object(genericPool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Status.Addresses.Retiring", Equal(0)))
+
+			claim = newClaim("test1", namespace, "InClusterIPPool", genericPool.GetName())
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			createdClaimNames = append(createdClaimNames, claim.Name)
+			Eventually(findAddress("test1", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.11")))
+		})
 	})
 
 	Context("when the pool has IPAddresses", func() {
@@ -212,7 +295,198 @@ var _ = Describe("IP Pool Reconciler", func() {
 			Entry("validates InClusterIPPool", "InClusterIPPool"),
 			Entry("validates GlobalInClusterIPPool", "GlobalInClusterIPPool"),
 		)
+
+		DescribeTable("releases the IPAddresses and fails the claims when the deletion policy is Cascade", func(poolType string) {
+			pool := newPool(poolType, poolName, namespace, "10.0.1.2", []string{"10.0.1.1-10.0.1.254"}, 24)
+			pool.PoolSpec().DeletionPolicy = v1alpha2.DeletionPolicyCascade
+			Expect(k8sClient.Create(context.Background(), pool)).To(Succeed())
+			Eventually(Get(pool)).Should(Succeed())
+
+			claim := newClaim("cascade-pool-test", namespace, poolType, pool.GetName())
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+
+			addresses := ipamv1.IPAddressList{}
+			Eventually(ObjectList(&addresses, client.InNamespace(namespace))).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Items", HaveLen(1)))
+
+			Expect(k8sClient.Delete(context.Background(), pool)).To(Succeed())
+
+			Eventually(Get(pool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(Not(Succeed()))
+			Eventually(ObjectList(&addresses, client.InNamespace(namespace))).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Items", HaveLen(0)))
+
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&claim), &claim)).To(Succeed())
+				return conditions.Get(&claim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.PoolDeletingReason)),
+			))
+
+			deleteClaim("cascade-pool-test", namespace)
+		},
+			Entry("validates InClusterIPPool", "InClusterIPPool"),
+			Entry("validates GlobalInClusterIPPool", "GlobalInClusterIPPool"),
+		)
 	})
+
+	Context("when the pool has an owner cluster", func() {
+		const clusterName = "owner-cluster"
+
+		It("follows the lifecycle of the cluster", func() {
+			pool := newPool("InClusterIPPool", "owned-pool", namespace, "10.0.1.2", []string{"10.0.1.1-10.0.1.254"}, 24)
+			pool.PoolSpec().OwnerCluster = &corev1.LocalObjectReference{Name: clusterName}
+			Expect(k8sClient.Create(context.Background(), pool)).To(Succeed())
+
+			Consistently(Object(pool)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.OwnerReferences", BeEmpty()))
+
+			cluster := clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clusterName,
+					Namespace: namespace,
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &cluster)).To(Succeed())
+
+			Eventually(Object(pool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.OwnerReferences", ContainElement(And(
+					HaveField("Kind", Equal("Cluster")),
+					HaveField("Name", Equal(clusterName)),
+					HaveField("UID", Equal(cluster.UID)),
+				))))
+
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&cluster), &cluster)).To(Succeed())
+			cluster.Spec.Paused = true
+			Expect(k8sClient.Update(context.Background(), &cluster)).To(Succeed())
+			Eventually(Object(pool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.Annotations", HaveKeyWithValue(clusterv1.PausedAnnotation, v1alpha2.PausedByOwnerCluster)))
+
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&cluster), &cluster)).To(Succeed())
+			cluster.Spec.Paused = false
+			Expect(k8sClient.Update(context.Background(), &cluster)).To(Succeed())
+			Eventually(Object(pool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.Annotations", Not(HaveKey(clusterv1.PausedAnnotation))))
+
+			claim := newClaim("owned-pool-test", namespace, "InClusterIPPool", pool.GetName())
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			Eventually(findAddress("owned-pool-test", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(Succeed())
+
+			deleteCluster(clusterName, namespace)
+
+			Consistently(Get(pool)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(Succeed())
+
+			deleteClaim("owned-pool-test", namespace)
+
+			Eventually(Get(pool)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(Not(Succeed()))
+		})
+	})
+
+	Context("when the pool has child pools", func() {
+		It("does not allocate the addresses of the children and reports their usage", func() {
+			parent := newPool("GlobalInClusterIPPool", "parent-pool", "", "", []string{"10.20.0.0/24"}, 24)
+			Expect(k8sClient.Create(context.Background(), parent)).To(Succeed())
+
+			child := newPool("InClusterIPPool", "child-pool", namespace, "", []string{"10.20.0.0/28"}, 24)
+			child.PoolSpec().ParentRef = &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: parent.GetName()}
+			Expect(k8sClient.Create(context.Background(), child)).To(Succeed())
+
+			childClaim := newClaim("child-pool-test", namespace, "InClusterIPPool", child.GetName())
+			Expect(k8sClient.Create(context.Background(), &childClaim)).To(Succeed())
+			parentClaim := newClaim("parent-pool-test", namespace, "GlobalInClusterIPPool", parent.GetName())
+			Expect(k8sClient.Create(context.Background(), &parentClaim)).To(Succeed())
+
+			Eventually(findAddress("child-pool-test", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.20.0.1")))
+			Eventually(findAddress("parent-pool-test", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.20.0.16")))
+
+			Eventually(Object(parent)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(And(
+				HaveField("Status.Addresses", Equal(&v1alpha2.InClusterIPPoolStatusIPAddresses{Total: 239, Used: 1, Free: 238})),
+				HaveField("Status.ChildAddresses", Equal(&v1alpha2.InClusterIPPoolStatusIPAddresses{Total: 15, Used: 1, Free: 14})),
+				HaveField("Status.ChildPools", ConsistOf(v1alpha2.ChildPool{
+					Kind:      "InClusterIPPool",
+					Namespace: namespace,
+					Name:      child.GetName(),
+					Addresses: "10.20.0.0/28",
+				})),
+			))
+
+			deleteClaim("child-pool-test", namespace)
+			deleteClaim("parent-pool-test", namespace)
+			deleteNamespacedPool(child.GetName(), namespace)
+			deleteClusterScopedPool(parent.GetName())
+		})
+	})
+})
+
+var _ = Describe("Pool validation by the CRD", func() {
+	var namespace string
+	BeforeEach(func() {
+		namespace = createNamespace()
+	})
+
+	// Webhooks don't run in envtest, so these pools are only validated by the
+	// CEL rules of the CRDs.
+	DescribeTable("it rejects invalid pools without the webhook",
+		func(poolType string, addresses []string, prefix int, gateway string, excludedAddresses []string, expectedError string) {
+			pool := newPool(poolType, "invalid-pool", namespace, gateway, addresses, prefix)
+			pool.PoolSpec().ExcludedAddresses = excludedAddresses
+			Expect(k8sClient.Create(context.Background(), pool)).To(MatchError(ContainSubstring(expectedError)))
+		},
+
+		Entry("When addresses are empty - InClusterIPPool",
+			"InClusterIPPool", []string{}, 24, "", nil, "should have at least 1 items"),
+		Entry("When an address is invalid - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10", "garbage"}, 24, "", nil, "provided address is not a valid IP, range, nor CIDR"),
+		Entry("When an address range is invalid - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-garbage"}, 24, "", nil, "provided address is not a valid IP, range, nor CIDR"),
+		Entry("When addresses are of mixed families - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10", "fd00::10"}, 24, "", nil, "provided addresses are of mixed IP families"),
+		Entry("When excluded addresses are of mixed families - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 24, "", []string{"10.0.0.11", "fd00::11"}, "provided addresses are of mixed IP families"),
+		Entry("When addresses and excluded addresses are of mixed families - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 24, "", []string{"fd00::11"}, "addresses and excluded addresses are of mixed IP families"),
+		Entry("When the gateway is invalid - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 24, "garbage", nil, "gateway must be a valid IP"),
+		Entry("When the gateway and addresses are of mixed families - InClusterIPPool",
+			"InClusterIPPool", []string{"fd00::10-fd00::20"}, 120, "10.0.0.1", nil, "provided gateway and addresses are of mixed IP families"),
+		Entry("When the prefix is missing - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 0, "", nil, "prefix must be between 1 and 32 for IPv4 and between 1 and 128 for IPv6"),
+		Entry("When the prefix is too long for IPv4 - InClusterIPPool",
+			"InClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 64, "", nil, "prefix must be between 1 and 32 for IPv4 and between 1 and 128 for IPv6"),
+
+		Entry("When an address is invalid - GlobalInClusterIPPool",
+			"GlobalInClusterIPPool", []string{"10.0.0.0/24[garbage]"}, 24, "", nil, "provided address is not a valid IP, range, nor CIDR"),
+		Entry("When the prefix is too long for IPv4 - GlobalInClusterIPPool",
+			"GlobalInClusterIPPool", []string{"10.0.0.10-10.0.0.20"}, 33, "", nil, "prefix must be between 1 and 32 for IPv4 and between 1 and 128 for IPv6"),
+	)
+
+	DescribeTable("it accepts all address notations",
+		func(addresses []string, prefix int) {
+			pool := newPool("InClusterIPPool", "valid-pool", namespace, "", addresses, prefix)
+			Expect(k8sClient.Create(context.Background(), pool)).To(Succeed())
+			deleteNamespacedPool(pool.GetName(), namespace)
+		},
+
+		Entry("IPs and CIDRs", []string{"10.0.0.10", "10.0.0.16/28"}, 24),
+		Entry("ranges with a shortened end", []string{"10.0.0.10-10.0.0.20", "10.0.0.30-40"}, 24),
+		Entry("netmasks and offsets", []string{"10.0.0.0/255.255.255.0[10-20]", "10.0.0.0/24[30]"}, 24),
+		Entry("IPv6 ranges with a suffix", []string{"fd00::10-fd00::20", "fd00::30-ff"}, 64),
+	)
 })
 
 func newPool(poolType, generateName, namespace, gateway string, addresses []string, prefix int) pooltypes.GenericInClusterPool {
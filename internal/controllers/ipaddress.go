@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/metrics"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/ipamutil"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/predicates"
+)
+
+// OrphanedIPAddressReconciler finds IPAddresses allocated from in-cluster pools
+// that are no longer backed by an IPAddressClaim, either because the claim was
+// removed without releasing the address, or because the claim belongs to a
+// Cluster that no longer exists. Orphaned addresses are marked with the
+// v1alpha2.OrphanedSinceAnnotation and released once GracePeriod has passed.
+// The claims of a Cluster that no longer exists are deleted instead, which
+// lets the claim controller release their address.
+type OrphanedIPAddressReconciler struct {
+	client.Client
+
+	// GracePeriod is the time an IPAddress has to be orphaned before it is
+	// released. Orphaned addresses are only reported when it is zero.
+	GracePeriod time.Duration
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OrphanedIPAddressReconciler) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("orphanedipaddress").
+		For(&ipamv1.IPAddress{}, builder.WithPredicates(
+			predicate.Or(
+				predicates.AddressReferencesPoolKind(metav1.GroupKind{Group: v1alpha2.GroupVersion.Group, Kind: inClusterIPPoolKind}),
+				predicates.AddressReferencesPoolKind(metav1.GroupKind{Group: v1alpha2.GroupVersion.Group, Kind: globalInClusterIPPoolKind}),
+			),
+		)).
+		Watches(
+			&ipamv1.IPAddressClaim{},
+			handler.EnqueueRequestsFromMapFunc(r.ipAddressClaimToIPAddress),
+			builder.WithPredicates(createOrDeletePredicate()),
+		).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterToIPAddresses),
+			builder.WithPredicates(createOrDeletePredicate()),
+		).
+		Complete(r)
+}
+
+func createOrDeletePredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return true },
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// ipAddressClaimToIPAddress maps a claim to its IPAddress.
+func (r *OrphanedIPAddressReconciler) ipAddressClaimToIPAddress(_ context.Context, clientObj client.Object) []reconcile.Request {
+	claim, ok := clientObj.(*ipamv1.IPAddressClaim)
+	if !ok {
+		return nil
+	}
+	return claimToIPAddresses(claim)
+}
+
+// claimToIPAddresses returns requests for the IPAddress sharing the name of the
+// claim and for the IPAddress referenced by the claim, which differs from it
+// once the claim has been migrated to another pool.
+func claimToIPAddresses(claim *ipamv1.IPAddressClaim) []reconcile.Request {
+	requests := []reconcile.Request{{
+		NamespacedName: types.NamespacedName{
+			Namespace: claim.Namespace,
+			Name:      claim.Name,
+		},
+	}}
+	if ref := claim.Status.AddressRef.Name; ref != "" && ref != claim.Name {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: claim.Namespace,
+				Name:      ref,
+			},
+		})
+	}
+	return requests
+}
+
+func (r *OrphanedIPAddressReconciler) clusterToIPAddresses(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := r.Client.List(ctx, claims,
+		client.InNamespace(clientObj.GetNamespace()),
+		client.MatchingLabels{clusterv1.ClusterNameLabel: clientObj.GetName()},
+	); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(claims.Items))
+	for i := range claims.Items {
+		requests = append(requests, claimToIPAddresses(&claims.Items[i])...)
+	}
+	return requests
+}
+
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+// Reconcile checks whether an IPAddress is orphaned, marks it accordingly and
+// releases it once the grace period has expired.
+func (r *OrphanedIPAddressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	address := &ipamv1.IPAddress{}
+	if err := r.Client.Get(ctx, req.NamespacedName, address); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to fetch IPAddress")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Only addresses that are managed by this provider and not already on
+	// their way out are of interest.
+	if !address.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(address, ipamutil.ProtectAddressFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	orphaned, reason, claim, err := r.isOrphaned(ctx, address)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(address, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !orphaned {
+		if _, ok := address.Annotations[v1alpha2.OrphanedSinceAnnotation]; ok {
+			log.Info("IPAddress is no longer orphaned")
+			delete(address.Annotations, v1alpha2.OrphanedSinceAnnotation)
+			return ctrl.Result{}, patchHelper.Patch(ctx, address)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	since, err := time.Parse(time.RFC3339, address.Annotations[v1alpha2.OrphanedSinceAnnotation])
+	if err != nil {
+		log.Info("IPAddress is orphaned", "reason", reason)
+		since = now
+		if address.Annotations == nil {
+			address.Annotations = map[string]string{}
+		}
+		address.Annotations[v1alpha2.OrphanedSinceAnnotation] = since.UTC().Format(time.RFC3339)
+		if err := patchHelper.Patch(ctx, address); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if r.GracePeriod == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := since.Add(r.GracePeriod).Sub(now); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if claim != nil {
+		// The claim controller releases the address of a claim whose Cluster
+		// no longer exists once the claim is deleted.
+		if !claim.DeletionTimestamp.IsZero() {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Deleting IPAddressClaim of orphaned IPAddress", "reason", reason, "orphanedSince", since, "IPAddressClaim", claim.Name)
+		if err := r.Client.Delete(ctx, claim); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to delete IPAddressClaim of orphaned IPAddress")
+		}
+	} else {
+		log.Info("Releasing orphaned IPAddress", "reason", reason, "orphanedSince", since)
+		controllerutil.RemoveFinalizer(address, ipamutil.ProtectAddressFinalizer)
+		if err := patchHelper.Patch(ctx, address); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Delete(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to delete orphaned IPAddress")
+		}
+	}
+
+	poolNamespace := address.Namespace
+	if address.Spec.PoolRef.Kind == globalInClusterIPPoolKind {
+		poolNamespace = ""
+	}
+	metrics.ReleasedOrphanedAddresses.With(
+		metrics.PoolLabels(address.Spec.PoolRef.Kind, poolNamespace, address.Spec.PoolRef.Name),
+	).Inc()
+
+	return ctrl.Result{}, nil
+}
+
+// isOrphaned reports whether the address is orphaned and why. If the address
+// is orphaned because the Cluster of its claim no longer exists, the claim is
+// returned as well.
+func (r *OrphanedIPAddressReconciler) isOrphaned(ctx context.Context, address *ipamv1.IPAddress) (bool, string, *ipamv1.IPAddressClaim, error) {
+	claim := &ipamv1.IPAddressClaim{}
+	claimKey := types.NamespacedName{Namespace: address.Namespace, Name: address.Spec.ClaimRef.Name}
+	if err := r.Client.Get(ctx, claimKey, claim); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, "IPAddressClaim not found", nil, nil
+		}
+		return false, "", nil, errors.Wrap(err, "failed to fetch IPAddressClaim")
+	}
+
+	clusterName, ok := claim.Labels[clusterv1.ClusterNameLabel]
+	if !ok {
+		return false, "", nil, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: clusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, "Cluster not found", claim, nil
+		}
+		return false, "", nil, errors.Wrap(err, "failed to fetch Cluster")
+	}
+
+	return false, "", nil, nil
+}
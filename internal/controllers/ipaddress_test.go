@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/ipamutil"
+)
+
+var _ = Describe("OrphanedIPAddressReconciler", func() {
+	const poolName = "test-pool"
+
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = createNamespace()
+
+		pool := v1alpha2.InClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      poolName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.1-10.0.0.254"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+		Eventually(Get(&pool)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteNamespacedPool(poolName, namespace)
+	})
+
+	forceRemoveClaim := func(name string) {
+		claim := ipamv1.IPAddressClaim{}
+		Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &claim)).To(Succeed())
+		controllerutil.RemoveFinalizer(&claim, ipamutil.ReleaseAddressFinalizer)
+		Expect(k8sClient.Update(context.Background(), &claim)).To(Succeed())
+		deleteClaim(name, namespace)
+	}
+
+	poolCondition := func() func() *clusterv1.Condition {
+		return func() *clusterv1.Condition {
+			pool := v1alpha2.InClusterIPPool{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: poolName}, &pool); err != nil {
+				return nil
+			}
+			return conditions.Get(&pool, v1alpha2.AddressesClaimedCondition)
+		}
+	}
+
+	It("should report and release addresses whose claim was removed", func() {
+		claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.0.2")))
+		Eventually(poolCondition()).Should(HaveField("Status", Equal(corev1.ConditionTrue)))
+
+		forceRemoveClaim("test")
+
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("ObjectMeta.Annotations", HaveKey(v1alpha2.OrphanedSinceAnnotation)))
+		Eventually(poolCondition()).Should(And(
+			HaveField("Status", Equal(corev1.ConditionFalse)),
+			HaveField("Reason", Equal(v1alpha2.OrphanedAddressesReason)),
+		))
+
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(2 * orphanedAddressGracePeriod).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		Eventually(poolCondition()).Should(HaveField("Status", Equal(corev1.ConditionTrue)))
+	})
+
+	It("should release the address referenced by a removed claim that does not share its name", func() {
+		// Claims that have been migrated to another pool reference the
+		// address of their companion claim.
+		claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+		claim.Annotations = map[string]string{clusterv1.PausedAnnotation: ""}
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+
+		address := ipamv1.IPAddress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "migrated-address",
+				Namespace:  namespace,
+				Finalizers: []string{ipamutil.ProtectAddressFinalizer},
+			},
+			Spec: ipamv1.IPAddressSpec{
+				ClaimRef: corev1.LocalObjectReference{Name: "test"},
+				PoolRef:  claim.Spec.PoolRef,
+				Address:  "10.0.0.99",
+				Prefix:   24,
+				Gateway:  "10.0.0.1",
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &address)).To(Succeed())
+
+		claim.Status.AddressRef = corev1.LocalObjectReference{Name: address.Name}
+		Expect(k8sClient.Status().Update(context.Background(), &claim)).To(Succeed())
+		Eventually(Object(&claim)).Should(HaveField("Status.AddressRef.Name", Equal(address.Name)))
+		Consistently(Object(&address)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("ObjectMeta.Annotations", Not(HaveKey(v1alpha2.OrphanedSinceAnnotation))))
+
+		forceRemoveClaim("test")
+
+		Eventually(Get(&address)).
+			WithTimeout(2 * orphanedAddressGracePeriod).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+	})
+
+	It("should report addresses whose claim belongs to a deleted cluster and delete the claim", func() {
+		const clusterName = "test-cluster"
+		cluster := clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterName,
+				Namespace: namespace,
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &cluster)).To(Succeed())
+
+		claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+		claim.Labels = map[string]string{clusterv1.ClusterNameLabel: clusterName}
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.0.2")))
+
+		deleteCluster(clusterName, namespace)
+
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("ObjectMeta.Annotations", HaveKey(v1alpha2.OrphanedSinceAnnotation)))
+
+		// The address is released by the claim controller once the claim has
+		// been deleted.
+		Eventually(Get(&claim)).
+			WithTimeout(2 * orphanedAddressGracePeriod).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+	})
+
+	It("should not touch addresses whose claim still exists", func() {
+		claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.0.2")))
+
+		Consistently(findAddress("test", namespace)).
+			WithTimeout(2 * orphanedAddressGracePeriod).WithPolling(100 * time.Millisecond).Should(
+			HaveField("ObjectMeta.Annotations", Not(HaveKey(v1alpha2.OrphanedSinceAnnotation))))
+
+		deleteClaim("test", namespace)
+	})
+})
@@ -19,14 +19,24 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"go4.org/netipx"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	clusterutil "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,6 +48,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/index"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/metrics"
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/poolutil"
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/ipamutil"
 	ipampredicates "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/predicates"
@@ -50,6 +61,10 @@ const (
 
 	// ProtectAddressFinalizer is used to prevent deletion of an IPAddress object while its claim is not deleted.
 	ProtectAddressFinalizer = "ipam.cluster.x-k8s.io/ProtectAddress"
+
+	// olderClaimsRequeueAfter is the delay after which a claim that waits for
+	// older claims of its pool is checked again.
+	olderClaimsRequeueAfter = 2 * time.Second
 )
 
 type genericInClusterPool interface {
@@ -61,6 +76,17 @@ type genericInClusterPool interface {
 type InClusterProviderAdapter struct {
 	Client           client.Client
 	WatchFilterValue string
+
+	// APIReader is used to list the sub-pools of GlobalInClusterIPPools
+	// without going through the cache. It defaults to Client.
+	APIReader client.Reader
+	// SubPoolLock must be shared with the ClusterSubPoolReconciler. It is held
+	// from finding a free address of a GlobalInClusterIPPool with
+	// ClusterSubPools until the address is created, so that sub-pools are
+	// never carved out of addresses that are being allocated.
+	SubPoolLock *sync.Mutex
+
+	rateLimiter *poolutil.AllocationRateLimiter
 }
 
 var _ ipamutil.ProviderAdapter = &InClusterProviderAdapter{}
@@ -68,14 +94,24 @@ var _ ipamutil.ProviderAdapter = &InClusterProviderAdapter{}
 // IPAddressClaimHandler reconciles a InClusterIPPool object.
 type IPAddressClaimHandler struct {
 	client.Client
-	claim *ipamv1.IPAddressClaim
-	pool  genericInClusterPool
+	claim       *ipamv1.IPAddressClaim
+	pool        genericInClusterPool
+	rateLimiter *poolutil.AllocationRateLimiter
+	apiReader   client.Reader
+	subPoolLock *sync.Mutex
+	unlock      func()
+	reserved    []poolutil.RateLimit
 }
 
-var _ ipamutil.ClaimHandler = &IPAddressClaimHandler{}
+var (
+	_ ipamutil.ClaimHandler          = &IPAddressClaimHandler{}
+	_ ipamutil.AddressEnsuredHandler = &IPAddressClaimHandler{}
+)
 
 // SetupWithManager sets up the controller with the Manager.
 func (i *InClusterProviderAdapter) SetupWithManager(_ context.Context, b *ctrl.Builder) error {
+	i.rateLimiter = poolutil.NewAllocationRateLimiter()
+
 	b.
 		For(&ipamv1.IPAddressClaim{}, builder.WithPredicates(
 			predicate.Or(
@@ -103,15 +139,167 @@ func (i *InClusterProviderAdapter) SetupWithManager(_ context.Context, b *ctrl.B
 			handler.EnqueueRequestsFromMapFunc(i.inClusterIPPoolToIPClaims("GlobalInClusterIPPool")),
 			builder.WithPredicates(resourceTransitionedToUnpaused()),
 		).
-		Owns(&ipamv1.IPAddress{}, builder.WithPredicates(
-			ipampredicates.AddressReferencesPoolKind(metav1.GroupKind{
-				Group: v1alpha2.GroupVersion.Group,
-				Kind:  inClusterIPPoolKind,
-			}),
-		))
+		// Changes to the spec of a pool might make room for pending claims of
+		// the pool and of its parent.
+		Watches(
+			&v1alpha2.InClusterIPPool{},
+			i.inClusterIPPoolToPendingIPClaims("InClusterIPPool"),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&v1alpha2.GlobalInClusterIPPool{},
+			i.inClusterIPPoolToPendingIPClaims("GlobalInClusterIPPool"),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Released addresses can be allocated to pending claims of the pool and
+		// of the pools that share its addresses.
+		Watches(
+			&ipamv1.IPAddress{},
+			handler.EnqueueRequestsFromMapFunc(i.ipAddressToPendingIPClaims),
+			builder.WithPredicates(addressReferencesInClusterPool(), resourceDeleted()),
+		).
+		Owns(&ipamv1.IPAddress{}, builder.WithPredicates(addressReferencesInClusterPool()))
 	return nil
 }
 
+func addressReferencesInClusterPool() predicate.Predicate {
+	return predicate.Or(
+		ipampredicates.AddressReferencesPoolKind(metav1.GroupKind{
+			Group: v1alpha2.GroupVersion.Group,
+			Kind:  inClusterIPPoolKind,
+		}),
+		ipampredicates.AddressReferencesPoolKind(metav1.GroupKind{
+			Group: v1alpha2.GroupVersion.Group,
+			Kind:  globalInClusterIPPoolKind,
+		}),
+	)
+}
+
+func resourceDeleted() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// inClusterIPPoolToPendingIPClaims enqueues the pending claims of a pool and
+// of its parent, as the addresses of child pools and sub-pools are not
+// allocated from the parent. On updates, the parent of the old pool is
+// covered as well, since it gets the addresses back if the parent changed.
+func (i *InClusterProviderAdapter) inClusterIPPoolToPendingIPClaims(kind string) handler.EventHandler {
+	enqueue := func(ctx context.Context, q workqueue.RateLimitingInterface, objs ...client.Object) {
+		for _, obj := range objs {
+			for _, request := range i.poolToPendingIPClaims(ctx, kind, obj) {
+				q.Add(request)
+			}
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(ctx, q, e.Object)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(ctx, q, e.ObjectNew, e.ObjectOld)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			enqueue(ctx, q, e.Object)
+		},
+	}
+}
+
+func (i *InClusterProviderAdapter) poolToPendingIPClaims(ctx context.Context, kind string, a client.Object) []reconcile.Request {
+	requests := i.pendingIPClaims(ctx, kind, a.GetName(), a.GetNamespace())
+
+	pool, ok := a.(pooltypes.GenericInClusterPool)
+	if !ok {
+		return requests
+	}
+	parent, err := poolutil.GetParentPool(ctx, i.Client, pool)
+	if err != nil || parent == nil {
+		return requests
+	}
+	return append(requests, i.pendingIPClaims(ctx, poolutil.PoolKind(parent), parent.GetName(), parent.GetNamespace())...)
+}
+
+func (i *InClusterProviderAdapter) ipAddressToPendingIPClaims(ctx context.Context, a client.Object) []reconcile.Request {
+	address, ok := a.(*ipamv1.IPAddress)
+	if !ok {
+		return nil
+	}
+
+	kind, name := address.Spec.PoolRef.Kind, address.Spec.PoolRef.Name
+	namespace := address.Namespace
+	if kind == globalInClusterIPPoolKind {
+		namespace = ""
+	}
+	requests := i.pendingIPClaims(ctx, kind, name, namespace)
+
+	// Pools that overlap with the pool of the address can allocate the
+	// released address as well.
+	addressIPSet, err := poolutil.AddressToIPSet(address.Spec.Address)
+	if err != nil {
+		return requests
+	}
+	pools, err := poolutil.ListPools(ctx, i.Client)
+	if err != nil {
+		return requests
+	}
+	for _, pool := range pools {
+		if poolutil.PoolKind(pool) == kind && pool.GetName() == name && pool.GetNamespace() == namespace {
+			continue
+		}
+		poolIPSet, err := poolutil.PoolSpecToIPSet(pool.PoolSpec())
+		if err != nil || !poolIPSet.Overlaps(addressIPSet) {
+			continue
+		}
+		requests = append(requests, i.pendingIPClaims(ctx, poolutil.PoolKind(pool), pool.GetName(), pool.GetNamespace())...)
+	}
+	return requests
+}
+
+// pendingIPClaims returns requests for all claims of a pool that don't have an
+// address yet, oldest first. Newer claims wait for older ones in
+// EnsureAddress, see waitForOlderClaims.
+func (i *InClusterProviderAdapter) pendingIPClaims(ctx context.Context, kind, name, namespace string) []reconcile.Request {
+	claims := &ipamv1.IPAddressClaimList{}
+	err := i.Client.List(ctx, claims,
+		client.MatchingFields{
+			"index.poolRef": index.IPPoolRefValue(corev1.TypedLocalObjectReference{
+				Name:     name,
+				Kind:     kind,
+				APIGroup: &v1alpha2.GroupVersion.Group,
+			}),
+		},
+		client.InNamespace(namespace),
+	)
+	if err != nil {
+		return nil
+	}
+
+	pending := []ipamv1.IPAddressClaim{}
+	for _, claim := range claims.Items {
+		if claim.Status.AddressRef.Name == "" && claim.DeletionTimestamp.IsZero() {
+			pending = append(pending, claim)
+		}
+	}
+	slices.SortFunc(pending, func(a, b ipamv1.IPAddressClaim) int {
+		return compareClaimAge(&a, &b)
+	})
+
+	requests := make([]reconcile.Request, 0, len(pending))
+	for _, claim := range pending {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      claim.Name,
+				Namespace: claim.Namespace,
+			},
+		})
+	}
+	return requests
+}
+
 func (i *InClusterProviderAdapter) inClusterIPPoolToIPClaims(kind string) func(context.Context, client.Object) []reconcile.Request {
 	return func(ctx context.Context, a client.Object) []reconcile.Request {
 		pool := a.(pooltypes.GenericInClusterPool)
@@ -145,9 +333,16 @@ func (i *InClusterProviderAdapter) inClusterIPPoolToIPClaims(kind string) func(c
 
 // ClaimHandlerFor returns a claim handler for a specific claim.
 func (i *InClusterProviderAdapter) ClaimHandlerFor(_ client.Client, claim *ipamv1.IPAddressClaim) ipamutil.ClaimHandler {
+	apiReader := i.APIReader
+	if apiReader == nil {
+		apiReader = i.Client
+	}
 	return &IPAddressClaimHandler{
-		Client: i.Client,
-		claim:  claim,
+		Client:      i.Client,
+		claim:       claim,
+		rateLimiter: i.rateLimiter,
+		apiReader:   apiReader,
+		subPoolLock: i.SubPoolLock,
 	}
 }
 
@@ -157,11 +352,13 @@ func (i *InClusterProviderAdapter) ClaimHandlerFor(_ client.Client, claim *ipamv
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools/finalizers,verbs=update
-//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;update;patch;delete
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims/status;ipaddresses/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims/status;ipaddresses/finalizers,verbs=update
 //+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 // FetchPool fetches the (Global)InClusterIPPool.
 func (h *IPAddressClaimHandler) FetchPool(ctx context.Context) (client.Object, *ctrl.Result, error) {
@@ -187,11 +384,31 @@ func (h *IPAddressClaimHandler) FetchPool(ctx context.Context) (client.Object, *
 		return nil, nil, nil
 	}
 
+	// Claims that are being deleted must always be able to release their address.
+	if h.claim.DeletionTimestamp.IsZero() {
+		allowed, err := poolutil.NamespaceAllowed(ctx, h.Client, h.pool.PoolSpec(), h.claim.Namespace)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to check allowed namespaces")
+		}
+		if !allowed {
+			log.Info("Namespace of IPAddressClaim is not allowed to use the referenced pool", "pool", h.pool.GetName())
+			conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.NamespaceNotAllowedReason, clusterv1.ConditionSeverityError,
+				"namespace %s is not allowed to allocate addresses from pool %s", h.claim.Namespace, h.pool.GetName())
+			return nil, &ctrl.Result{}, nil
+		}
+	}
+
 	return h.pool, nil, nil
 }
 
 // EnsureAddress ensures that the IPAddress contains a valid address.
 func (h *IPAddressClaimHandler) EnsureAddress(ctx context.Context, address *ipamv1.IPAddress) (*ctrl.Result, error) {
+	// Claims that have been switched to a new pool by a PoolMigration use the
+	// address of their companion claim and must not allocate from the old pool.
+	if claimMigrated(h.claim) {
+		return &ctrl.Result{}, nil
+	}
+
 	addressesInUse, err := poolutil.ListAddressesInUse(ctx, h.Client, h.pool.GetNamespace(), h.claim.Spec.PoolRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list addresses: %w", err)
@@ -202,30 +419,373 @@ func (h *IPAddressClaimHandler) EnsureAddress(ctx context.Context, address *ipam
 	})
 
 	if !allocated {
+		if !h.pool.GetDeletionTimestamp().IsZero() {
+			conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.PoolDeletingReason, clusterv1.ConditionSeverityError,
+				"pool %s is being deleted", h.pool.GetName())
+			return nil, fmt.Errorf("pool %s is being deleted", h.pool.GetName())
+		}
+
 		poolSpec := h.pool.PoolSpec()
-		inUseIPSet, err := poolutil.AddressesToIPSet(buildAddressList(addressesInUse, poolSpec.Gateway))
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert IPAddressList to IPSet: %w", err)
+		if len(poolSpec.Quotas) > 0 {
+			clusterNames, err := poolutil.ClaimClusterNames(ctx, h.Client, h.pool.GetNamespace(), h.claim.Spec.PoolRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list claims: %w", err)
+			}
+			if err := poolutil.CheckQuotas(poolSpec.Quotas, addressesInUse, clusterNames, h.claim.Namespace, h.claim.Labels[clusterv1.ClusterNameLabel]); err != nil {
+				conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.QuotaExceededReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+				return nil, err
+			}
 		}
 
-		poolIPSet, err := poolutil.PoolSpecToIPSet(poolSpec)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert pool to range: %w", err)
+		if h.claim.Annotations[v1alpha2.GatewayClaimAnnotation] != "true" {
+			// Claims are served first come, first served.
+			if res, err := h.waitForOlderClaims(ctx); err != nil || res != nil {
+				return res, err
+			}
 		}
 
-		freeIP, err := poolutil.FindFreeAddress(poolIPSet, inUseIPSet)
+		h.lockSubPools()
+
+		var ip netip.Addr
+		if h.claim.Annotations[v1alpha2.GatewayClaimAnnotation] == "true" {
+			ip, err = h.gatewayAddress(ctx, addressesInUse)
+		} else {
+			ip, err = h.findFreeAddress(ctx, addressesInUse)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to find free address: %w", err)
+			return nil, err
+		}
+
+		if res := h.reserveAllocation(); res != nil {
+			return res, nil
 		}
 
-		address.Spec.Address = freeIP.String()
+		address.Spec.Address = ip.String()
 		address.Spec.Gateway = poolSpec.Gateway
 		address.Spec.Prefix = poolSpec.Prefix
 	}
 
+	if err := poolutil.SetNetworkMetadataAnnotations(address, h.pool.PoolSpec()); err != nil {
+		return nil, fmt.Errorf("failed to set network metadata: %w", err)
+	}
+
+	conditions.MarkTrue(h.claim, clusterv1.ReadyCondition)
+
 	return nil, nil
 }
 
+// gatewayAddress returns the gateway of the pool for a claim that requested
+// it, if it is not allocated yet.
+func (h *IPAddressClaimHandler) gatewayAddress(ctx context.Context, addressesInUse []ipamv1.IPAddress) (netip.Addr, error) {
+	poolSpec := h.pool.PoolSpec()
+	if poolSpec.Gateway == "" {
+		conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.GatewayUnavailableReason, clusterv1.ConditionSeverityError,
+			"pool %s has no gateway", h.pool.GetName())
+		return netip.Addr{}, fmt.Errorf("pool %s has no gateway", h.pool.GetName())
+	}
+
+	gatewayIPSet, err := poolutil.GatewayIPSet(poolSpec)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to parse gateway: %w", err)
+	}
+
+	// The gateway must not be allocated twice, neither from this pool nor
+	// from another pool containing or sharing it.
+	gatewayPoolAddresses, err := poolutil.ListAddressesOfGatewayPools(ctx, h.Client, h.pool, gatewayIPSet)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to list addresses of pools with the gateway: %w", err)
+	}
+	inUseIPSet, err := poolutil.AddressesToIPSet(buildAddressList(append(addressesInUse, gatewayPoolAddresses...), ""))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert IPAddressList to IPSet: %w", err)
+	}
+
+	gateway, err := poolutil.FindFreeAddress(gatewayIPSet, inUseIPSet)
+	if err != nil {
+		conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.GatewayUnavailableReason, clusterv1.ConditionSeverityWarning,
+			"gateway %s of pool %s is already allocated", poolSpec.Gateway, h.pool.GetName())
+		return netip.Addr{}, fmt.Errorf("gateway %s of pool %s is already allocated", poolSpec.Gateway, h.pool.GetName())
+	}
+	return gateway, nil
+}
+
+// findFreeAddress returns a free address of the pool for the claim.
+func (h *IPAddressClaimHandler) findFreeAddress(ctx context.Context, addressesInUse []ipamv1.IPAddress) (netip.Addr, error) {
+	poolSpec := h.pool.PoolSpec()
+	poolIPSet, err := poolutil.PoolSpecToIPSet(poolSpec)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert pool to range: %w", err)
+	}
+
+	builder := &netipx.IPSetBuilder{}
+	builder.AddSet(poolIPSet)
+	if h.claim.Spec.PoolRef.Kind == globalInClusterIPPoolKind {
+		// Addresses carved out for sub-pools are allocated from the sub-pools
+		// only. The cache may miss sub-pools that were just carved out.
+		subPools, err := poolutil.ListSubPools(ctx, h.apiReader, h.pool.GetName())
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("failed to list sub-pools: %w", err)
+		}
+		subPoolIPSet, err := poolutil.SubPoolsToIPSet(subPools)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("failed to convert sub-pools to IPSet: %w", err)
+		}
+		builder.RemoveSet(subPoolIPSet)
+	}
+
+	// Addresses of child pools are allocated from the child pools only.
+	childPools, err := poolutil.ListChildPools(ctx, h.Client, h.pool)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to list child pools: %w", err)
+	}
+	childPoolIPSet, err := poolutil.ChildPoolsToIPSet(childPools)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert child pools to IPSet: %w", err)
+	}
+	builder.RemoveSet(childPoolIPSet)
+
+	// Reserved addresses are used outside of Cluster API and never allocated.
+	reservedIPSet, err := poolutil.ReservedAddressesToIPSet(poolSpec, poolIPSet)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert reserved addresses to IPSet: %w", err)
+	}
+	builder.RemoveSet(reservedIPSet)
+
+	var failureDomain string
+	if len(poolSpec.AddressSubsets) > 0 {
+		// The failure domain is only looked up for pools with address subsets.
+		if failureDomain, err = h.failureDomain(ctx); err != nil {
+			return netip.Addr{}, err
+		}
+	}
+	failureDomainLabel := poolutil.FailureDomainLabel(poolSpec)
+	useSubsets := failureDomain != ""
+	if useSubsets {
+		// Only allocate from the address subsets of the failure domain of the claim.
+		subsetIPSet, err := poolutil.AddressSubsetsToIPSet(poolSpec, failureDomainLabel, failureDomain)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("failed to convert address subsets to IPSet: %w", err)
+		}
+		builder.Intersect(subsetIPSet)
+	}
+
+	purpose := poolutil.ClaimPurpose(h.claim)
+	if purpose != "" || len(poolSpec.PurposeRanges) > 0 {
+		// Claims with a purpose are only allocated addresses of the ranges
+		// with their purpose, all other claims only addresses outside of them.
+		purposeIPSet, err := poolutil.PurposeRangesToIPSet(poolSpec, purpose)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("failed to convert purpose ranges to IPSet: %w", err)
+		}
+		if purpose != "" {
+			builder.Intersect(purposeIPSet)
+		} else {
+			builder.RemoveSet(purposeIPSet)
+		}
+	}
+
+	if poolIPSet, err = builder.IPSet(); err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert pool to range: %w", err)
+	}
+	if purpose != "" && poolutil.IPSetCount(poolIPSet) == 0 {
+		conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.PurposeExhaustedReason, clusterv1.ConditionSeverityError,
+			"pool %s has no addresses with purpose %s", h.pool.GetName(), purpose)
+		return netip.Addr{}, fmt.Errorf("pool %s has no addresses with purpose %s", h.pool.GetName(), purpose)
+	}
+	if useSubsets && poolutil.IPSetCount(poolIPSet) == 0 {
+		conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.FailureDomainExhaustedReason, clusterv1.ConditionSeverityError,
+			"pool %s has no addresses with %s=%s", h.pool.GetName(), failureDomainLabel, failureDomain)
+		return netip.Addr{}, fmt.Errorf("pool %s has no addresses with %s=%s", h.pool.GetName(), failureDomainLabel, failureDomain)
+	}
+
+	// Addresses allocated from overlapping pools must not be allocated twice.
+	overlappingAddresses, err := poolutil.ListAddressesOfOverlappingPools(ctx, h.Client, h.pool, poolIPSet)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to list addresses of overlapping pools: %w", err)
+	}
+
+	inUseIPSet, err := poolutil.AddressesToIPSet(buildAddressList(append(addressesInUse, overlappingAddresses...), poolSpec.Gateway))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to convert IPAddressList to IPSet: %w", err)
+	}
+
+	freeIP, err := poolutil.FindFreeAddress(poolIPSet, inUseIPSet)
+	if err != nil {
+		if purpose != "" {
+			conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.PurposeExhaustedReason, clusterv1.ConditionSeverityWarning,
+				"all addresses of pool %s with purpose %s are allocated", h.pool.GetName(), purpose)
+			return netip.Addr{}, fmt.Errorf("all addresses of pool %s with purpose %s are allocated", h.pool.GetName(), purpose)
+		}
+		if useSubsets {
+			conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.FailureDomainExhaustedReason, clusterv1.ConditionSeverityWarning,
+				"all addresses of pool %s with %s=%s are allocated", h.pool.GetName(), failureDomainLabel, failureDomain)
+			return netip.Addr{}, fmt.Errorf("all addresses of pool %s with %s=%s are allocated", h.pool.GetName(), failureDomainLabel, failureDomain)
+		}
+		return netip.Addr{}, fmt.Errorf("failed to find free address: %w", err)
+	}
+
+	return freeIP, nil
+}
+
+// failureDomain returns the failure domain of the claim. It is taken from the
+// failure domain label of the pool on the claim, or the failure domain of the
+// Machine owning the claim.
+func (h *IPAddressClaimHandler) failureDomain(ctx context.Context) (string, error) {
+	if fd := h.claim.Labels[poolutil.FailureDomainLabel(h.pool.PoolSpec())]; fd != "" {
+		return fd, nil
+	}
+
+	for _, ref := range h.claim.OwnerReferences {
+		if ref.Kind != "Machine" || !strings.HasPrefix(ref.APIVersion, clusterv1.GroupVersion.Group+"/") {
+			continue
+		}
+		machine := &clusterv1.Machine{}
+		if err := h.Client.Get(ctx, types.NamespacedName{Namespace: h.claim.Namespace, Name: ref.Name}, machine); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to fetch owning machine: %w", err)
+		}
+		if machine.Spec.FailureDomain != nil {
+			return *machine.Spec.FailureDomain, nil
+		}
+	}
+	return "", nil
+}
+
+// waitForOlderClaims returns a result that requeues the claim if an older
+// claim of the pool is still waiting for an address. Claims that wait for
+// something else than free addresses of the pool, e.g. because their quota is
+// exhausted or their Cluster is paused, don't hold back newer claims.
+func (h *IPAddressClaimHandler) waitForOlderClaims(ctx context.Context) (*ctrl.Result, error) {
+	claims, err := poolutil.ListClaims(ctx, h.Client, h.pool.GetNamespace(), h.claim.Spec.PoolRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claims: %w", err)
+	}
+
+	for i := range claims {
+		claim := &claims[i]
+		if compareClaimAge(claim, h.claim) >= 0 || claim.Status.AddressRef.Name != "" || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if annotations.HasPaused(claim) || claim.Annotations[v1alpha2.GatewayClaimAnnotation] == "true" {
+			continue
+		}
+		if conditions.IsFalse(claim, clusterv1.ReadyCondition) && slices.Contains(claimSpecificReasons, conditions.GetReason(claim, clusterv1.ReadyCondition)) {
+			continue
+		}
+		if _, ok := claim.Labels[clusterv1.ClusterNameLabel]; ok {
+			cluster, err := clusterutil.GetClusterFromMetadata(ctx, h.Client, claim.ObjectMeta)
+			if apierrors.IsNotFound(err) || (err == nil && annotations.IsPaused(cluster, cluster)) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch cluster of claim %s/%s: %w", claim.Namespace, claim.Name, err)
+			}
+		}
+
+		conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.WaitingForOlderClaimsReason, clusterv1.ConditionSeverityInfo,
+			"waiting for older claim %s/%s of pool %s", claim.Namespace, claim.Name, h.pool.GetName())
+		return &ctrl.Result{RequeueAfter: olderClaimsRequeueAfter}, nil
+	}
+	return nil, nil
+}
+
+// claimSpecificReasons are the reasons of claims that cannot be fulfilled for
+// reasons that don't apply to other claims of the pool.
+var claimSpecificReasons = []string{
+	v1alpha2.NamespaceNotAllowedReason,
+	v1alpha2.QuotaExceededReason,
+	v1alpha2.RateLimitedReason,
+	v1alpha2.FailureDomainExhaustedReason,
+	v1alpha2.PurposeExhaustedReason,
+	v1alpha2.PoolDeletingReason,
+}
+
+// compareClaimAge orders claims by their creation timestamp. Creation
+// timestamps only have a resolution of seconds, so claims created within the
+// same second are ordered by namespace and name.
+func compareClaimAge(a, b *ipamv1.IPAddressClaim) int {
+	if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// reserveAllocation enforces the rate limits of the pool. If a limit is
+// exceeded, the claim is marked accordingly and a result is returned that
+// requeues it once the limit allows another allocation.
+func (h *IPAddressClaimHandler) reserveAllocation() *ctrl.Result {
+	rateLimits := h.pool.PoolSpec().RateLimits
+	if rateLimits == nil || h.rateLimiter == nil {
+		return nil
+	}
+
+	kind := h.claim.Spec.PoolRef.Kind
+	poolKey := fmt.Sprintf("%s/%s/%s", kind, h.pool.GetNamespace(), h.pool.GetName())
+	clusterName := h.claim.Labels[clusterv1.ClusterNameLabel]
+
+	var limits []poolutil.RateLimit
+	if rateLimits.AllocationsPerMinute > 0 {
+		limits = append(limits, poolutil.RateLimit{Key: poolKey, Limit: rateLimits.AllocationsPerMinute})
+	}
+	if rateLimits.AllocationsPerMinutePerCluster > 0 && clusterName != "" {
+		limits = append(limits, poolutil.RateLimit{
+			Key:   fmt.Sprintf("%s/%s/%s", poolKey, h.claim.Namespace, clusterName),
+			Limit: rateLimits.AllocationsPerMinutePerCluster,
+		})
+	}
+
+	exceeded, wait := h.rateLimiter.Reserve(limits...)
+	if exceeded == nil {
+		// The reservation is released by AddressEnsured if the address
+		// cannot be created.
+		h.reserved = limits
+		return nil
+	}
+
+	limit := "pool"
+	message := fmt.Sprintf("pool %s exceeded its rate limit of %d allocations per minute", h.pool.GetName(), exceeded.Limit)
+	if exceeded.Key != poolKey {
+		limit = "cluster"
+		message = fmt.Sprintf("cluster %s exceeded the rate limit of %d allocations per minute of pool %s", clusterName, exceeded.Limit, h.pool.GetName())
+	}
+	metrics.RateLimitedAllocations.With(metrics.RateLimitLabels(kind, h.pool.GetNamespace(), h.pool.GetName(), limit)).Inc()
+	conditions.MarkFalse(h.claim, clusterv1.ReadyCondition, v1alpha2.RateLimitedReason, clusterv1.ConditionSeverityWarning,
+		"%s, retrying in %s", message, wait.Round(time.Second))
+
+	return &ctrl.Result{RequeueAfter: wait}
+}
+
+// lockSubPools holds the SubPoolLock while an address of a
+// GlobalInClusterIPPool with ClusterSubPools is allocated. It is released by
+// AddressEnsured.
+func (h *IPAddressClaimHandler) lockSubPools() {
+	pool, ok := h.pool.(*v1alpha2.GlobalInClusterIPPool)
+	if !ok || pool.Spec.ClusterSubPools == nil || h.subPoolLock == nil || h.unlock != nil {
+		return
+	}
+	h.subPoolLock.Lock()
+	h.unlock = h.subPoolLock.Unlock
+}
+
+// AddressEnsured releases the SubPoolLock once the address has been created.
+// If the address could not be created, the allocation no longer counts
+// towards the rate limits of the pool.
+func (h *IPAddressClaimHandler) AddressEnsured(_ context.Context, err error) {
+	if h.unlock != nil {
+		h.unlock()
+		h.unlock = nil
+	}
+	if err != nil && len(h.reserved) > 0 {
+		h.rateLimiter.Release(h.reserved...)
+	}
+	h.reserved = nil
+}
+
 // ReleaseAddress releases the ip address.
 func (h *IPAddressClaimHandler) ReleaseAddress() (*ctrl.Result, error) {
 	// We don't need to do anything here, since the ip address is released when the IPAddress is deleted
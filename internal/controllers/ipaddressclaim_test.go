@@ -27,6 +27,7 @@ import (
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
@@ -683,7 +684,7 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 						Namespace: secondNamespace,
 					},
 					Spec: v1alpha2.InClusterIPPoolSpec{
-						Addresses: []string{"10.0.0.50"},
+						Addresses: []string{"10.0.0.50-10.0.0.51"},
 						Prefix:    24,
 						Gateway:   "10.0.0.1",
 					},
@@ -699,11 +700,10 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 				deleteNamespacedPool(commonPoolName, secondNamespace)
 			})
 
-			It("should allocate Addresses from each Pool independently", func() {
+			It("should allocate Addresses from each Pool", func() {
 				claim1 = newClaim("test-1", namespace, "InClusterIPPool", commonPoolName)
 				claim2 = newClaim("test-2", secondNamespace, "InClusterIPPool", commonPoolName)
 				Expect(k8sClient.Create(context.Background(), &claim1)).To(Succeed())
-				Expect(k8sClient.Create(context.Background(), &claim2)).To(Succeed())
 
 				expectedAddress1 := ipamv1.IPAddress{
 					ObjectMeta: metav1.ObjectMeta{
@@ -773,7 +773,7 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 							Kind:     "InClusterIPPool",
 							Name:     commonPoolName,
 						},
-						Address: "10.0.0.50",
+						Address: "10.0.0.51",
 						Prefix:  24,
 						Gateway: "10.0.0.1",
 					},
@@ -783,6 +783,8 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
 					EqualObject(&expectedAddress1, IgnoreAutogeneratedMetadata, IgnoreUIDsOnIPAddress))
 
+				// The pools overlap, the address of the first pool is not allocated twice.
+				Expect(k8sClient.Create(context.Background(), &claim2)).To(Succeed())
 				Eventually(findAddress("test-2", secondNamespace)).
 					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
 					EqualObject(&expectedAddress2, IgnoreAutogeneratedMetadata, IgnoreUIDsOnIPAddress))
@@ -813,7 +815,7 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 						Name: commonPoolName,
 					},
 					Spec: v1alpha2.InClusterIPPoolSpec{
-						Addresses: []string{"10.0.0.50"},
+						Addresses: []string{"10.0.0.50-10.0.0.51"},
 						Prefix:    24,
 						Gateway:   "10.0.0.1",
 					},
@@ -830,7 +832,7 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 				deleteClusterScopedPool(commonPoolName)
 			})
 
-			It("should allocate Addresses from each Pool independently", func() {
+			It("should allocate Addresses from each Pool", func() {
 				claimFromNamespacedPool = newClaim("test-1", namespace, "InClusterIPPool", commonPoolName)
 				claimFromGlobalPool = newClaim("test-2", namespace, "GlobalInClusterIPPool", commonPoolName)
 
@@ -902,25 +904,158 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 							Kind:     "GlobalInClusterIPPool",
 							Name:     commonPoolName,
 						},
-						Address: "10.0.0.50",
+						Address: "10.0.0.51",
 						Prefix:  24,
 						Gateway: "10.0.0.1",
 					},
 				}
 
 				Expect(k8sClient.Create(context.Background(), &claimFromNamespacedPool)).To(Succeed())
-				Expect(k8sClient.Create(context.Background(), &claimFromGlobalPool)).To(Succeed())
-
 				Eventually(findAddress(expectedAddress1.GetName(), namespace)).
 					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
 					EqualObject(&expectedAddress1, IgnoreAutogeneratedMetadata, IgnoreUIDsOnIPAddress))
 
+				// The pools overlap, the address of the namespaced pool is not allocated twice.
+				Expect(k8sClient.Create(context.Background(), &claimFromGlobalPool)).To(Succeed())
 				Eventually(findAddress(expectedAddress2.GetName(), namespace)).
 					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
 					EqualObject(&expectedAddress2, IgnoreAutogeneratedMetadata, IgnoreUIDsOnIPAddress))
 			})
 		})
 
+		When("a namespaced and a global pool overlap", func() {
+			const commonPoolName = "overlapping-pool"
+			var claimFromNamespacedPool, claimFromGlobalPool ipamv1.IPAddressClaim
+
+			BeforeEach(func() {
+				spec := v1alpha2.InClusterIPPoolSpec{
+					Addresses:    []string{"10.0.0.50-10.0.0.51"},
+					Prefix:       24,
+					Gateway:      "10.0.0.1",
+					AllowOverlap: true,
+				}
+
+				namespacedPool := v1alpha2.InClusterIPPool{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      commonPoolName,
+						Namespace: namespace,
+					},
+					Spec: spec,
+				}
+				Expect(k8sClient.Create(context.Background(), &namespacedPool)).To(Succeed())
+				Eventually(Get(&namespacedPool)).Should(Succeed())
+
+				globalPool := v1alpha2.GlobalInClusterIPPool{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: commonPoolName,
+					},
+					Spec: spec,
+				}
+				Expect(k8sClient.Create(context.Background(), &globalPool)).To(Succeed())
+				Eventually(Get(&globalPool)).Should(Succeed())
+			})
+
+			AfterEach(func() {
+				deleteClaim(claimFromNamespacedPool.Name, claimFromNamespacedPool.Namespace)
+				deleteClaim(claimFromGlobalPool.Name, claimFromGlobalPool.Namespace)
+				deleteNamespacedPool(commonPoolName, namespace)
+				deleteClusterScopedPool(commonPoolName)
+			})
+
+			It("should not allocate an address that is allocated from the other pool", func() {
+				claimFromNamespacedPool = newClaim("test-1", namespace, "InClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromNamespacedPool)).To(Succeed())
+				Eventually(findAddress("test-1", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.50")))
+
+				claimFromGlobalPool = newClaim("test-2", namespace, "GlobalInClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromGlobalPool)).To(Succeed())
+				Eventually(findAddress("test-2", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.51")))
+			})
+
+			It("should allocate an address released by the other pool to a pending claim", func() {
+				claimFromNamespacedPool = newClaim("test-1", namespace, "InClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromNamespacedPool)).To(Succeed())
+				Eventually(findAddress("test-1", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.50")))
+
+				claim := newClaim("test-3", namespace, "InClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+				Eventually(findAddress("test-3", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.51")))
+
+				claimFromGlobalPool = newClaim("test-2", namespace, "GlobalInClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromGlobalPool)).To(Succeed())
+				Consistently(findAddress("test-2", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+
+				deleteClaim("test-3", namespace)
+				Eventually(findAddress("test-2", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.51")))
+			})
+		})
+
+		When("a namespaced and a global pool overlap without allowOverlap", func() {
+			const commonPoolName = "legacy-overlapping-pool"
+			var claimFromNamespacedPool, claimFromGlobalPool ipamv1.IPAddressClaim
+
+			BeforeEach(func() {
+				// Such pools are rejected by the webhook, but may have been
+				// created before overlapping pools were rejected.
+				spec := v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.50-10.0.0.51"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+				}
+
+				namespacedPool := v1alpha2.InClusterIPPool{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      commonPoolName,
+						Namespace: namespace,
+					},
+					Spec: spec,
+				}
+				Expect(k8sClient.Create(context.Background(), &namespacedPool)).To(Succeed())
+				Eventually(Get(&namespacedPool)).Should(Succeed())
+
+				globalPool := v1alpha2.GlobalInClusterIPPool{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: commonPoolName,
+					},
+					Spec: spec,
+				}
+				Expect(k8sClient.Create(context.Background(), &globalPool)).To(Succeed())
+				Eventually(Get(&globalPool)).Should(Succeed())
+			})
+
+			AfterEach(func() {
+				deleteClaim(claimFromNamespacedPool.Name, claimFromNamespacedPool.Namespace)
+				deleteClaim(claimFromGlobalPool.Name, claimFromGlobalPool.Namespace)
+				deleteNamespacedPool(commonPoolName, namespace)
+				deleteClusterScopedPool(commonPoolName)
+			})
+
+			It("should not allocate an address that is allocated from the other pool", func() {
+				claimFromNamespacedPool = newClaim("test-1", namespace, "InClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromNamespacedPool)).To(Succeed())
+				Eventually(findAddress("test-1", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.50")))
+
+				claimFromGlobalPool = newClaim("test-2", namespace, "GlobalInClusterIPPool", commonPoolName)
+				Expect(k8sClient.Create(context.Background(), &claimFromGlobalPool)).To(Succeed())
+				Eventually(findAddress("test-2", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.51")))
+			})
+		})
+
 		When("the pool is paused", func() {
 			When("a claim is created", func() {
 				const poolName = "paused-pool"
@@ -1625,6 +1760,65 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 					HaveField("Items", HaveLen(0)))
 			})
 		})
+
+		Context("When the cluster has been deleted", func() {
+			AfterEach(func() {
+				deleteNamespacedPool(poolName, namespace)
+			})
+
+			It("releases the address when the claim is deleted", func() {
+				cluster = clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      clusterName,
+						Namespace: namespace,
+					},
+				}
+				Expect(k8sClient.Create(context.Background(), &cluster)).To(Succeed())
+				Eventually(Get(&cluster)).Should(Succeed())
+
+				claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+				claim.ObjectMeta.Labels = map[string]string{
+					clusterv1.ClusterNameLabel: clusterName,
+				}
+				Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+				Eventually(findAddress("test", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.1")))
+
+				deleteCluster(clusterName, namespace)
+				deleteClaim("test", namespace)
+
+				Eventually(findAddress("test", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+			})
+
+			It("deletes the claim and releases its address once the claim is orphaned", func() {
+				cluster = clusterv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      clusterName,
+						Namespace: namespace,
+					},
+				}
+				Expect(k8sClient.Create(context.Background(), &cluster)).To(Succeed())
+				Eventually(Get(&cluster)).Should(Succeed())
+
+				claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+				claim.ObjectMeta.Labels = map[string]string{
+					clusterv1.ClusterNameLabel: clusterName,
+				}
+				Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+				Eventually(findAddress("test", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+					HaveField("Spec.Address", Equal("10.0.0.1")))
+
+				deleteCluster(clusterName, namespace)
+
+				Eventually(Get(&claim)).
+					WithTimeout(2 * orphanedAddressGracePeriod).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+				Eventually(findAddress("test", namespace)).
+					WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+			})
+		})
 	})
 
 	Context("When the ipaddressclaim is paused", func() {
@@ -1714,6 +1908,430 @@ var _ = Describe("IPAddressClaimReconciler", func() {
 				EqualObject(&expectedIPAddress, IgnoreAutogeneratedMetadata, IgnoreUIDsOnIPAddress))
 		})
 	})
+
+	Context("When the pool has network metadata", func() {
+		const poolName = "test-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      poolName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.1-10.0.0.254"},
+					Prefix:    24,
+					Gateway:   "10.0.0.2",
+					NetworkMetadata: &v1alpha2.NetworkMetadata{
+						DNSServers: []string{"10.0.0.3"},
+						MTU:        9000,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test", namespace)
+			deleteNamespacedPool(poolName, namespace)
+		})
+
+		It("should copy the network metadata onto the address and keep it up to date", func() {
+			claim := newClaim("test", namespace, "InClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+
+			Eventually(findAddress("test", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.Annotations", Equal(map[string]string{
+					v1alpha2.DNSServersAnnotation: "10.0.0.3",
+					v1alpha2.MTUAnnotation:        "9000",
+					v1alpha2.NetmaskAnnotation:    "255.255.255.0",
+				})))
+
+			pool := v1alpha2.InClusterIPPool{}
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: poolName}, &pool)).To(Succeed())
+			pool.Spec.NetworkMetadata.MTU = 0
+			pool.Spec.NetworkMetadata.VLANID = 42
+			Expect(k8sClient.Update(context.Background(), &pool)).To(Succeed())
+
+			// the pool watch only reacts to unpausing, so we touch the claim to trigger a reconcile
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&claim), &claim)).To(Succeed())
+			claim.Labels = map[string]string{"touched": "true"}
+			Expect(k8sClient.Update(context.Background(), &claim)).To(Succeed())
+
+			Eventually(findAddress("test", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("ObjectMeta.Annotations", Equal(map[string]string{
+					v1alpha2.DNSServersAnnotation: "10.0.0.3",
+					v1alpha2.VLANIDAnnotation:     "42",
+					v1alpha2.NetmaskAnnotation:    "255.255.255.0",
+				})))
+		})
+	})
+
+	Context("When a GlobalInClusterIPPool restricts the allowed namespaces", func() {
+		const poolName = "restricted-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.GlobalInClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: poolName,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.1-10.0.0.254"},
+					Prefix:    24,
+					Gateway:   "10.0.0.2",
+					AllowedNamespaces: &v1alpha2.AllowedNamespaces{
+						Names: []string{namespace2},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test", namespace)
+			deleteClaim("test", namespace2)
+			deleteClusterScopedPool(poolName)
+		})
+
+		It("should only allocate addresses for claims from allowed namespaces", func() {
+			claim := newClaim("test", namespace, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			allowedClaim := newClaim("test", namespace2, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &allowedClaim)).To(Succeed())
+
+			Eventually(findAddress("test", namespace2)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.1")))
+
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&claim), &claim)).To(Succeed())
+				return conditions.Get(&claim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.NamespaceNotAllowedReason)),
+			))
+			Consistently(findAddress("test", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		})
+	})
+
+	Context("When a GlobalInClusterIPPool is exhausted", func() {
+		const poolName = "exhausted-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.GlobalInClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: poolName,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.60"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test-2", namespace)
+			deleteClaim("test-3", namespace)
+			deleteClusterScopedPool(poolName)
+		})
+
+		It("should allocate addresses to pending claims as soon as capacity becomes available, oldest first", func() {
+			claim := newClaim("test-1", namespace, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			Eventually(findAddress("test-1", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.60")))
+
+			for _, name := range []string{"test-2", "test-3"} {
+				claim := newClaim(name, namespace, "GlobalInClusterIPPool", poolName)
+				Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			}
+			Consistently(findAddress("test-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+
+			deleteClaim("test-1", namespace)
+			Eventually(findAddress("test-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.60")))
+
+			pool := v1alpha2.GlobalInClusterIPPool{}
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: poolName}, &pool)).To(Succeed())
+			pool.Spec.Addresses = []string{"10.0.0.60-10.0.0.61"}
+			Expect(k8sClient.Update(context.Background(), &pool)).To(Succeed())
+			Eventually(findAddress("test-3", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.61")))
+		})
+
+		It("should not allocate an address to a newer claim while an older claim is pending", func() {
+			pool := v1alpha2.GlobalInClusterIPPool{}
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: poolName}, &pool)).To(Succeed())
+			pool.Annotations = map[string]string{clusterv1.PausedAnnotation: ""}
+			Expect(k8sClient.Update(context.Background(), &pool)).To(Succeed())
+
+			// The newer claim sorts first by name, so it is reconciled first
+			// once the pool is unpaused.
+			older := newClaim("test-3", namespace, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &older)).To(Succeed())
+			time.Sleep(1100 * time.Millisecond) // creation timestamps have a resolution of seconds
+			newer := newClaim("test-2", namespace, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &newer)).To(Succeed())
+
+			Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: poolName}, &pool)).To(Succeed())
+			delete(pool.Annotations, clusterv1.PausedAnnotation)
+			Expect(k8sClient.Update(context.Background(), &pool)).To(Succeed())
+
+			Eventually(findAddress("test-3", namespace)).
+				WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.60")))
+			Consistently(findAddress("test-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		})
+	})
+
+	Context("When a pool has rate limits", func() {
+		const poolName = "rate-limited-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      poolName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.70-10.0.0.79"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+					RateLimits: &v1alpha2.RateLimits{
+						AllocationsPerMinute: 1,
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test-1", namespace)
+			deleteClaim("test-2", namespace)
+			deleteNamespacedPool(poolName, namespace)
+		})
+
+		It("should delay allocations exceeding the limit", func() {
+			claim := newClaim("test-1", namespace, "InClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			Eventually(findAddress("test-1", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.70")))
+
+			limitedClaim := newClaim("test-2", namespace, "InClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &limitedClaim)).To(Succeed())
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&limitedClaim), &limitedClaim)).To(Succeed())
+				return conditions.Get(&limitedClaim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.RateLimitedReason)),
+			))
+			Consistently(findAddress("test-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		})
+	})
+
+	Context("When a pool has address subsets", func() {
+		const poolName = "zoned-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      poolName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.80-10.0.0.89"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+					AddressSubsets: []v1alpha2.AddressSubset{
+						{Addresses: []string{"10.0.0.84-10.0.0.85"}, Labels: map[string]string{corev1.LabelTopologyZone: "a"}},
+						{Addresses: []string{"10.0.0.88"}, Labels: map[string]string{corev1.LabelTopologyZone: "b"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test-a", namespace)
+			deleteClaim("test-b", namespace)
+			deleteClaim("test-b-2", namespace)
+			deleteNamespacedPool(poolName, namespace)
+		})
+
+		It("should allocate addresses from the subset of the failure domain", func() {
+			claimA := newClaim("test-a", namespace, "InClusterIPPool", poolName)
+			claimA.Labels = map[string]string{corev1.LabelTopologyZone: "a"}
+			Expect(k8sClient.Create(context.Background(), &claimA)).To(Succeed())
+			Eventually(findAddress("test-a", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.84")))
+
+			claimB := newClaim("test-b", namespace, "InClusterIPPool", poolName)
+			claimB.Labels = map[string]string{corev1.LabelTopologyZone: "b"}
+			Expect(k8sClient.Create(context.Background(), &claimB)).To(Succeed())
+			Eventually(findAddress("test-b", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.88")))
+
+			exhaustedClaim := newClaim("test-b-2", namespace, "InClusterIPPool", poolName)
+			exhaustedClaim.Labels = map[string]string{corev1.LabelTopologyZone: "b"}
+			Expect(k8sClient.Create(context.Background(), &exhaustedClaim)).To(Succeed())
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&exhaustedClaim), &exhaustedClaim)).To(Succeed())
+				return conditions.Get(&exhaustedClaim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.FailureDomainExhaustedReason)),
+			))
+			Consistently(findAddress("test-b-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		})
+	})
+
+	Context("When a pool has purpose ranges", func() {
+		const poolName = "purpose-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      poolName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.90-10.0.0.99"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+					PurposeRanges: []v1alpha2.PurposeRange{
+						{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.90-10.0.0.91"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test-vip", namespace)
+			deleteClaim("test-node", namespace)
+			deleteClaim("test-other", namespace)
+			deleteNamespacedPool(poolName, namespace)
+		})
+
+		It("should allocate addresses from the ranges of the purpose", func() {
+			vipClaim := newClaim("test-vip", namespace, "InClusterIPPool", poolName)
+			vipClaim.Annotations = map[string]string{v1alpha2.PurposeLabel: "vip"}
+			Expect(k8sClient.Create(context.Background(), &vipClaim)).To(Succeed())
+			Eventually(findAddress("test-vip", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.90")))
+
+			nodeClaim := newClaim("test-node", namespace, "InClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &nodeClaim)).To(Succeed())
+			Eventually(findAddress("test-node", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.92")))
+
+			pool := v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: poolName, Namespace: namespace}}
+			Eventually(Object(&pool)).WithTimeout(time.Second).Should(
+				HaveField("Status.PurposeUsage", Equal([]v1alpha2.PurposeUsage{
+					{Purpose: "vip", Total: 2, Used: 1, Free: 1},
+				})))
+
+			otherClaim := newClaim("test-other", namespace, "InClusterIPPool", poolName)
+			otherClaim.Labels = map[string]string{v1alpha2.PurposeLabel: "control-plane"}
+			Expect(k8sClient.Create(context.Background(), &otherClaim)).To(Succeed())
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&otherClaim), &otherClaim)).To(Succeed())
+				return conditions.Get(&otherClaim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.PurposeExhaustedReason)),
+			))
+		})
+	})
+
+	Context("When a claim requests the gateway of the pool", func() {
+		const poolName = "router-pool"
+
+		BeforeEach(func() {
+			pool := v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      poolName,
+					Namespace: namespace,
+				},
+				Spec: v1alpha2.InClusterIPPoolSpec{
+					Addresses: []string{"10.0.0.10-10.0.0.20"},
+					Prefix:    24,
+					Gateway:   "10.0.0.1",
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+			Eventually(Get(&pool)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim("test-router", namespace)
+			deleteClaim("test-router-2", namespace)
+			deleteClaim("test-node", namespace)
+			deleteNamespacedPool(poolName, namespace)
+		})
+
+		It("should allocate the gateway exactly once", func() {
+			routerClaim := newClaim("test-router", namespace, "InClusterIPPool", poolName)
+			routerClaim.Annotations = map[string]string{v1alpha2.GatewayClaimAnnotation: "true"}
+			Expect(k8sClient.Create(context.Background(), &routerClaim)).To(Succeed())
+			Eventually(findAddress("test-router", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec", And(
+					HaveField("Address", Equal("10.0.0.1")),
+					HaveField("Gateway", Equal("10.0.0.1")),
+				)))
+
+			nodeClaim := newClaim("test-node", namespace, "InClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &nodeClaim)).To(Succeed())
+			Eventually(findAddress("test-node", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+				HaveField("Spec.Address", Equal("10.0.0.10")))
+
+			pool := v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: poolName, Namespace: namespace}}
+			Eventually(Object(&pool)).WithTimeout(time.Second).Should(
+				HaveField("Status.Addresses", And(
+					HaveField("Used", Equal(1)),
+					HaveField("OutOfRange", Equal(0)),
+				)))
+
+			secondRouterClaim := newClaim("test-router-2", namespace, "InClusterIPPool", poolName)
+			secondRouterClaim.Annotations = map[string]string{v1alpha2.GatewayClaimAnnotation: "true"}
+			Expect(k8sClient.Create(context.Background(), &secondRouterClaim)).To(Succeed())
+			Eventually(func() *clusterv1.Condition {
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&secondRouterClaim), &secondRouterClaim)).To(Succeed())
+				return conditions.Get(&secondRouterClaim, clusterv1.ReadyCondition)
+			}).Should(And(
+				HaveField("Status", Equal(corev1.ConditionFalse)),
+				HaveField("Reason", Equal(v1alpha2.GatewayUnavailableReason)),
+			))
+			Consistently(findAddress("test-router-2", namespace)).
+				WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+		})
+	})
 })
 
 func createNamespace() string {
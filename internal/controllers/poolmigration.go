@@ -0,0 +1,383 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/index"
+)
+
+// PoolMigrationReconciler migrates the IPAddressClaims of a pool to another
+// pool. For every claim it creates a companion claim referencing the new pool,
+// which pre-allocates the new address, and exposes the name of the new
+// IPAddress on the claim and its current IPAddress. Once the claim is
+// annotated as acknowledged, its status is switched to the new IPAddress and
+// the old IPAddress is released.
+//
+// Companion claims are controlled by the PoolMigration until the switch, so
+// deleting an unfinished PoolMigration releases the pre-allocated addresses.
+// After the switch they are controlled by the migrated claim and are deleted
+// together with it.
+type PoolMigrationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PoolMigrationReconciler) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha2.PoolMigration{}).
+		Watches(
+			&ipamv1.IPAddressClaim{},
+			handler.EnqueueRequestsFromMapFunc(r.ipAddressClaimToPoolMigrations)).
+		Watches(
+			&ipamv1.IPAddress{},
+			handler.EnqueueRequestsFromMapFunc(r.ipAddressToPoolMigrations)).
+		Complete(r)
+}
+
+func (r *PoolMigrationReconciler) ipAddressClaimToPoolMigrations(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	claim, ok := clientObj.(*ipamv1.IPAddressClaim)
+	if !ok {
+		return nil
+	}
+
+	if name, ok := claim.Labels[v1alpha2.MigrationLabel]; ok {
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: name},
+		}}
+	}
+	return r.poolMigrationsFrom(ctx, claim.Namespace, claim.Spec.PoolRef)
+}
+
+// ipAddressToPoolMigrations enqueues the PoolMigrations of the pool of an
+// IPAddress, so that an old address that is recreated by a claim reconcile
+// racing with the switch is released again.
+func (r *PoolMigrationReconciler) ipAddressToPoolMigrations(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	address, ok := clientObj.(*ipamv1.IPAddress)
+	if !ok {
+		return nil
+	}
+	return r.poolMigrationsFrom(ctx, address.Namespace, address.Spec.PoolRef)
+}
+
+func (r *PoolMigrationReconciler) poolMigrationsFrom(ctx context.Context, namespace string, poolRef corev1.TypedLocalObjectReference) []reconcile.Request {
+	migrations := &v1alpha2.PoolMigrationList{}
+	if err := r.Client.List(ctx, migrations, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, migration := range migrations.Items {
+		if !poolReferenceMatches(migration.Spec.From, poolRef) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: migration.Namespace, Name: migration.Name},
+		})
+	}
+	return requests
+}
+
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=poolmigrations,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=poolmigrations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile pre-allocates the new addresses of a PoolMigration and switches
+// the claims that acknowledged the migration.
+func (r *PoolMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	migration := &v1alpha2.PoolMigration{}
+	if err := r.Client.Get(ctx, req.NamespacedName, migration); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to fetch PoolMigration")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Companion claims of unfinished migrations are garbage collected.
+	if !migration.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(migration, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, migration); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := r.Client.List(ctx, claims,
+		client.InNamespace(migration.Namespace),
+		client.MatchingFields{index.IPAddressClaimPoolRefCombinedField: index.IPPoolRefValue(migrationPoolRef(migration.Spec.From))},
+	); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list IPAddressClaims")
+	}
+
+	status := v1alpha2.PoolMigrationStatus{Conditions: migration.Status.Conditions}
+	var errs []error
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if !poolReferenceMatches(migration.Spec.From, claim.Spec.PoolRef) || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if _, ok := claim.Labels[v1alpha2.MigrationLabel]; ok {
+			continue
+		}
+
+		companionName := migrationClaimName(claim, migration)
+		// Claims that have been switched by another PoolMigration are left alone.
+		if ref := claim.Status.AddressRef.Name; ref != "" && ref != claim.Name && ref != companionName {
+			continue
+		}
+
+		status.Claims++
+		allocated, completed, err := r.reconcileClaim(ctx, migration, claim, companionName)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to migrate IPAddressClaim %s", claim.Name))
+			continue
+		}
+		if allocated {
+			status.Allocated++
+		}
+		if completed {
+			log.V(1).Info("IPAddressClaim has been migrated", "IPAddressClaim", claim.Name, "IPAddress", companionName)
+			status.Completed++
+		}
+	}
+
+	migration.Status = status
+	switch {
+	case status.Allocated < status.Claims:
+		conditions.MarkFalse(migration, v1alpha2.MigrationCompletedCondition, v1alpha2.AllocatingAddressesReason, clusterv1.ConditionSeverityInfo,
+			"%d of %d addresses allocated", status.Allocated, status.Claims)
+	case status.Completed < status.Claims:
+		conditions.MarkFalse(migration, v1alpha2.MigrationCompletedCondition, v1alpha2.WaitingForAcknowledgementReason, clusterv1.ConditionSeverityInfo,
+			"%d of %d claims switched", status.Completed, status.Claims)
+	default:
+		conditions.MarkTrue(migration, v1alpha2.MigrationCompletedCondition)
+	}
+
+	return ctrl.Result{}, kerrors.NewAggregate(errs)
+}
+
+// reconcileClaim migrates a single claim. It returns whether the new address
+// has been allocated and whether the claim has been switched to it.
+func (r *PoolMigrationReconciler) reconcileClaim(ctx context.Context, migration *v1alpha2.PoolMigration, claim *ipamv1.IPAddressClaim, companionName string) (bool, bool, error) {
+	companion := &ipamv1.IPAddressClaim{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: companionName}, companion)
+	if apierrors.IsNotFound(err) {
+		return false, false, r.createCompanionClaim(ctx, migration, claim, companionName)
+	}
+	if err != nil {
+		return false, false, errors.Wrap(err, "failed to fetch companion IPAddressClaim")
+	}
+	// A claim of the same name that was not created by this migration must
+	// not be adopted. After the switch the companion is controlled by the claim.
+	if !metav1.IsControlledBy(companion, migration) && !metav1.IsControlledBy(companion, claim) {
+		return false, false, errors.Errorf("IPAddressClaim %s already exists and is not controlled by PoolMigration %s", companionName, migration.Name)
+	}
+
+	newAddress := companion.Status.AddressRef.Name
+	if newAddress == "" {
+		return false, false, nil
+	}
+
+	if err := r.annotateMigrationAddress(ctx, claim, newAddress); err != nil {
+		return true, false, err
+	}
+
+	if claim.Annotations[v1alpha2.MigrationAcknowledgedAnnotation] != "true" {
+		return true, false, nil
+	}
+
+	if err := r.switchClaim(ctx, claim, companion); err != nil {
+		return true, false, err
+	}
+	return true, true, nil
+}
+
+func (r *PoolMigrationReconciler) createCompanionClaim(ctx context.Context, migration *v1alpha2.PoolMigration, claim *ipamv1.IPAddressClaim, name string) error {
+	companion := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: claim.Namespace,
+			Labels: map[string]string{
+				v1alpha2.MigrationLabel: migration.Name,
+			},
+		},
+		Spec: ipamv1.IPAddressClaimSpec{
+			PoolRef: migrationPoolRef(migration.Spec.To),
+		},
+	}
+	// The companion claim counts towards the same cluster as the migrated claim.
+	if clusterName, ok := claim.Labels[clusterv1.ClusterNameLabel]; ok {
+		companion.Labels[clusterv1.ClusterNameLabel] = clusterName
+	}
+	if err := controllerutil.SetControllerReference(migration, companion, r.Scheme); err != nil {
+		return errors.Wrap(err, "failed to set owner reference")
+	}
+
+	if err := r.Client.Create(ctx, companion); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "failed to create companion IPAddressClaim")
+	}
+	return nil
+}
+
+// annotateMigrationAddress exposes the name of the new IPAddress on the claim
+// and on its current IPAddress.
+func (r *PoolMigrationReconciler) annotateMigrationAddress(ctx context.Context, claim *ipamv1.IPAddressClaim, newAddress string) error {
+	if claim.Annotations[v1alpha2.MigrationAddressAnnotation] != newAddress {
+		original := claim.DeepCopy()
+		if claim.Annotations == nil {
+			claim.Annotations = map[string]string{}
+		}
+		claim.Annotations[v1alpha2.MigrationAddressAnnotation] = newAddress
+		if err := r.Client.Patch(ctx, claim, client.MergeFrom(original)); err != nil {
+			return errors.Wrap(err, "failed to annotate IPAddressClaim")
+		}
+	}
+
+	address := &ipamv1.IPAddress{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name}, address); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to fetch IPAddress")
+	}
+	if address.Annotations[v1alpha2.MigrationAddressAnnotation] == newAddress || !address.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	original := address.DeepCopy()
+	if address.Annotations == nil {
+		address.Annotations = map[string]string{}
+	}
+	address.Annotations[v1alpha2.MigrationAddressAnnotation] = newAddress
+	if err := r.Client.Patch(ctx, address, client.MergeFrom(original)); err != nil {
+		return errors.Wrap(err, "failed to annotate IPAddress")
+	}
+	return nil
+}
+
+// switchClaim hands the companion claim over to the migrated claim, points
+// the claim to the new IPAddress and releases the old one. The steps are
+// idempotent, so an interrupted switch is completed by the next reconcile.
+func (r *PoolMigrationReconciler) switchClaim(ctx context.Context, claim, companion *ipamv1.IPAddressClaim) error {
+	if !metav1.IsControlledBy(companion, claim) {
+		original := companion.DeepCopy()
+		companion.OwnerReferences = nil
+		if err := controllerutil.SetControllerReference(claim, companion, r.Scheme); err != nil {
+			return errors.Wrap(err, "failed to set owner reference")
+		}
+		if err := r.Client.Patch(ctx, companion, client.MergeFrom(original)); err != nil {
+			return errors.Wrap(err, "failed to hand over companion IPAddressClaim")
+		}
+	}
+
+	if claim.Status.AddressRef.Name != companion.Status.AddressRef.Name {
+		original := claim.DeepCopy()
+		claim.Status.AddressRef = corev1.LocalObjectReference{Name: companion.Status.AddressRef.Name}
+		if err := r.Client.Status().Patch(ctx, claim, client.MergeFrom(original)); err != nil {
+			return errors.Wrap(err, "failed to switch IPAddressClaim to the new address")
+		}
+	}
+
+	address := &ipamv1.IPAddress{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name}, address); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to fetch IPAddress")
+	}
+	if controllerutil.RemoveFinalizer(address, ProtectAddressFinalizer) {
+		if err := r.Client.Update(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to remove address finalizer")
+		}
+	}
+	if err := r.Client.Delete(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to release old IPAddress")
+	}
+	return nil
+}
+
+// claimMigrated returns whether the claim has been switched to the address of
+// a companion claim by a PoolMigration, or is about to be because it
+// acknowledged the migration. The status of an acknowledged claim may not be
+// switched yet in the cache, so the annotation is checked as well.
+func claimMigrated(claim *ipamv1.IPAddressClaim) bool {
+	if claim.Annotations[v1alpha2.MigrationAcknowledgedAnnotation] == "true" && claim.Annotations[v1alpha2.MigrationAddressAnnotation] != "" {
+		return true
+	}
+	ref := claim.Status.AddressRef.Name
+	return ref != "" && ref != claim.Name
+}
+
+// migrationClaimName returns the name of the companion claim of a claim. Names
+// that exceed the maximum length are truncated and suffixed with a hash of the
+// full name to keep them unique.
+func migrationClaimName(claim *ipamv1.IPAddressClaim, migration *v1alpha2.PoolMigration) string {
+	name := fmt.Sprintf("%s-%s", claim.Name, migration.Name)
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	return strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.") + suffix
+}
+
+func migrationPoolRef(ref v1alpha2.PoolReference) corev1.TypedLocalObjectReference {
+	return corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(v1alpha2.GroupVersion.Group),
+		Kind:     ref.Kind,
+		Name:     ref.Name,
+	}
+}
+
+func poolReferenceMatches(ref v1alpha2.PoolReference, poolRef corev1.TypedLocalObjectReference) bool {
+	return poolRef.APIGroup != nil && *poolRef.APIGroup == v1alpha2.GroupVersion.Group &&
+		poolRef.Kind == ref.Kind && poolRef.Name == ref.Name
+}
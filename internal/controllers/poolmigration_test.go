@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("PoolMigrationReconciler", func() {
+	const (
+		oldPoolName   = "old-pool"
+		newPoolName   = "new-pool"
+		migrationName = "renumber"
+		claimName     = "test-claim"
+		companionName = "test-claim-renumber"
+	)
+
+	var namespace string
+
+	createPool := func(name string, addresses ...string) {
+		pool := v1alpha2.InClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: addresses,
+				Prefix:    16,
+				Gateway:   "10.0.0.1",
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+		Eventually(Get(&pool)).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		namespace = createNamespace()
+		createPool(oldPoolName, "10.0.0.10-10.0.0.20")
+		createPool(newPoolName, "10.0.1.10-10.0.1.20")
+
+		claim := newClaim(claimName, namespace, "InClusterIPPool", oldPoolName)
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+		Eventually(findAddress(claimName, namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.0.10")))
+	})
+
+	AfterEach(func() {
+		migration := v1alpha2.PoolMigration{ObjectMeta: metav1.ObjectMeta{Name: migrationName, Namespace: namespace}}
+		Expect(k8sClient.Delete(context.Background(), &migration)).To(Succeed())
+		Eventually(Get(&migration)).Should(Not(Succeed()))
+
+		// Companion claims are not garbage collected in envtest.
+		deleteClaim(companionName, namespace)
+		deleteClaim(claimName, namespace)
+		deleteNamespacedPool(oldPoolName, namespace)
+		deleteNamespacedPool(newPoolName, namespace)
+	})
+
+	It("switches claims to the new pool once they acknowledge the migration", func() {
+		migration := v1alpha2.PoolMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      migrationName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha2.PoolMigrationSpec{
+				From: v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: oldPoolName},
+				To:   v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: newPoolName},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &migration)).To(Succeed())
+
+		Eventually(findAddress(companionName, namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.1.10")))
+
+		claim := ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace}}
+		Eventually(Object(&claim)).Should(
+			HaveField("ObjectMeta.Annotations", HaveKeyWithValue(v1alpha2.MigrationAddressAnnotation, companionName)))
+		Eventually(findAddress(claimName, namespace)).Should(
+			HaveField("ObjectMeta.Annotations", HaveKeyWithValue(v1alpha2.MigrationAddressAnnotation, companionName)))
+		Eventually(Object(&migration)).Should(
+			HaveField("Status", And(
+				HaveField("Claims", Equal(1)),
+				HaveField("Allocated", Equal(1)),
+				HaveField("Completed", Equal(0)),
+			)))
+		Expect(conditions.Get(&migration, v1alpha2.MigrationCompletedCondition)).To(
+			HaveField("Reason", Equal(v1alpha2.WaitingForAcknowledgementReason)))
+
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&claim), &claim)).To(Succeed())
+		original := claim.DeepCopy()
+		claim.Annotations[v1alpha2.MigrationAcknowledgedAnnotation] = "true"
+		Expect(k8sClient.Patch(context.Background(), &claim, client.MergeFrom(original))).To(Succeed())
+
+		Eventually(Object(&claim)).Should(
+			HaveField("Status.AddressRef.Name", Equal(companionName)))
+		Eventually(Get(&ipamv1.IPAddress{ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace}})).
+			Should(Not(Succeed()))
+		Consistently(Get(&ipamv1.IPAddress{ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace}})).
+			WithTimeout(time.Second).Should(Not(Succeed()), "the old pool must not allocate a new address")
+
+		Eventually(Object(&migration)).Should(
+			HaveField("Status.Completed", Equal(1)))
+		Expect(conditions.Get(&migration, v1alpha2.MigrationCompletedCondition)).To(
+			HaveField("Status", Equal(corev1.ConditionTrue)))
+
+		companion := ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: companionName, Namespace: namespace}}
+		Eventually(Object(&companion)).Should(
+			HaveField("ObjectMeta.OwnerReferences", ContainElement(And(
+				HaveField("Kind", Equal("IPAddressClaim")),
+				HaveField("Name", Equal(claimName)),
+			))))
+
+		pool := v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: oldPoolName, Namespace: namespace}}
+		Eventually(Object(&pool)).Should(
+			HaveField("Status.Addresses.Used", Equal(0)))
+	})
+
+	It("does not adopt an existing claim with the name of the companion claim", func() {
+		existing := newClaim(companionName, namespace, "InClusterIPPool", newPoolName)
+		Expect(k8sClient.Create(context.Background(), &existing)).To(Succeed())
+
+		migration := v1alpha2.PoolMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      migrationName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha2.PoolMigrationSpec{
+				From: v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: oldPoolName},
+				To:   v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: newPoolName},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &migration)).To(Succeed())
+
+		claim := ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace}}
+		Consistently(Object(&claim)).WithTimeout(time.Second).Should(
+			HaveField("ObjectMeta.Annotations", Not(HaveKey(v1alpha2.MigrationAddressAnnotation))))
+		Expect(Object(&existing)()).To(
+			HaveField("ObjectMeta.OwnerReferences", BeEmpty()))
+		Expect(Object(&migration)()).To(
+			HaveField("Status.Allocated", Equal(0)))
+	})
+
+	It("limits the length of companion claim names", func() {
+		longName := strings.Repeat("a", 250)
+		first := migrationClaimName(
+			&ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: longName + "-1"}},
+			&v1alpha2.PoolMigration{ObjectMeta: metav1.ObjectMeta{Name: migrationName}})
+		second := migrationClaimName(
+			&ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: longName + "-2"}},
+			&v1alpha2.PoolMigration{ObjectMeta: metav1.ObjectMeta{Name: migrationName}})
+
+		Expect(first).To(HaveLen(253))
+		Expect(second).To(HaveLen(253))
+		Expect(first).NotTo(Equal(second))
+	})
+})
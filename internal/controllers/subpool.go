@@ -0,0 +1,268 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go4.org/netipx"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/poolutil"
+)
+
+// ClusterSubPoolReconciler creates an InClusterIPPool for every Cluster that
+// is matched by the ClusterSubPools of a GlobalInClusterIPPool. The addresses
+// of the InClusterIPPool are carved out of the free addresses of the
+// GlobalInClusterIPPool. The InClusterIPPool is bound to the Cluster through
+// its OwnerCluster, so it is deleted together with the Cluster, which returns
+// its addresses to the GlobalInClusterIPPool.
+type ClusterSubPoolReconciler struct {
+	client.Client
+
+	// APIReader is used to list existing sub-pools and allocated addresses
+	// without going through the cache, so that the same addresses are never
+	// carved out twice.
+	APIReader client.Reader
+
+	// SubPoolLock must be shared with the InClusterProviderAdapter. It is held
+	// while a sub-pool is carved out and created, so that the claim controller
+	// never allocates addresses of the GlobalInClusterIPPool in the meantime.
+	SubPoolLock *sync.Mutex
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterSubPoolReconciler) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	if r.SubPoolLock == nil {
+		return fmt.Errorf("error setting the manager: SubPoolLock is nil")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clustersubpool").
+		For(&clusterv1.Cluster{}).
+		Owns(&v1alpha2.InClusterIPPool{}).
+		Watches(
+			&v1alpha2.GlobalInClusterIPPool{},
+			handler.EnqueueRequestsFromMapFunc(r.globalInClusterIPPoolToClusters),
+		).
+		WithOptions(controller.Options{
+			// To avoid race conditions when carving out sub-pools, we explicitly set this to 1
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(r)
+}
+
+func (r *ClusterSubPoolReconciler) globalInClusterIPPoolToClusters(ctx context.Context, clientObj client.Object) []reconcile.Request {
+	pool, ok := clientObj.(*v1alpha2.GlobalInClusterIPPool)
+	if !ok || pool.Spec.ClusterSubPools == nil {
+		return nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&pool.Spec.ClusterSubPools.ClusterSelector)
+	if err != nil {
+		return nil
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	if err := r.Client.List(ctx, clusters, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(clusters.Items))
+	for _, cluster := range clusters.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: cluster.Namespace,
+				Name:      cluster.Name,
+			},
+		})
+	}
+	return requests
+}
+
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=globalinclusterippools,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=inclusterippools,verbs=get;list;watch;create
+
+// Reconcile ensures that a Cluster has a sub-pool of every GlobalInClusterIPPool
+// whose ClusterSubPools select it.
+func (r *ClusterSubPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrap(err, "failed to fetch Cluster")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// The sub-pools of a deleted Cluster are deleted by the pool controller.
+	if !cluster.DeletionTimestamp.IsZero() || annotations.IsPaused(cluster, cluster) {
+		return ctrl.Result{}, nil
+	}
+
+	pools := &v1alpha2.GlobalInClusterIPPoolList{}
+	if err := r.Client.List(ctx, pools); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list GlobalInClusterIPPools")
+	}
+
+	var errs []error
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if pool.Spec.ClusterSubPools == nil || !pool.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(&pool.Spec.ClusterSubPools.ClusterSelector)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid cluster selector of GlobalInClusterIPPool %s", pool.Name))
+			continue
+		}
+		if !selector.Matches(labels.Set(cluster.Labels)) {
+			continue
+		}
+
+		if err := r.ensureSubPool(ctx, pool, cluster); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return ctrl.Result{}, kerrors.NewAggregate(errs)
+}
+
+// ensureSubPool creates the sub-pool of the Cluster unless it already exists.
+func (r *ClusterSubPoolReconciler) ensureSubPool(ctx context.Context, pool *v1alpha2.GlobalInClusterIPPool, cluster *clusterv1.Cluster) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	r.SubPoolLock.Lock()
+	defer r.SubPoolLock.Unlock()
+
+	subPools, err := poolutil.ListSubPools(ctx, r.APIReader, pool.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to list sub-pools")
+	}
+	for _, subPool := range subPools {
+		if subPool.Namespace == cluster.Namespace && subPool.Labels[clusterv1.ClusterNameLabel] == cluster.Name {
+			return nil
+		}
+	}
+
+	prefix, err := r.carve(ctx, pool, subPools)
+	if err != nil {
+		return errors.Wrapf(err, "failed to carve sub-pool out of GlobalInClusterIPPool %s", pool.Name)
+	}
+
+	subPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      fmt.Sprintf("%s-%s", cluster.Name, pool.Name),
+			Labels: map[string]string{
+				v1alpha2.SubPoolOfLabel:    pool.Name,
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses:                   []string{prefix.String()},
+			Prefix:                      pool.Spec.Prefix,
+			Gateway:                     pool.Spec.Gateway,
+			AllocateReservedIPAddresses: pool.Spec.AllocateReservedIPAddresses,
+			ReservedAddressPolicy:       pool.Spec.ReservedAddressPolicy.DeepCopy(),
+			NetworkMetadata:             pool.Spec.NetworkMetadata.DeepCopy(),
+			OwnerCluster:                &corev1.LocalObjectReference{Name: cluster.Name},
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, subPool, r.Client.Scheme()); err != nil {
+		return err
+	}
+
+	if err := r.Client.Create(ctx, subPool); err != nil {
+		return errors.Wrap(err, "failed to create sub-pool")
+	}
+
+	log.Info("Created sub-pool", "pool", pool.Name, "subPool", subPool.Name, "addresses", prefix.String())
+	return nil
+}
+
+// carve finds a free block of addresses of the pool that is neither allocated
+// nor part of another sub-pool. It must be called with the SubPoolLock held.
+func (r *ClusterSubPoolReconciler) carve(ctx context.Context, pool *v1alpha2.GlobalInClusterIPPool, subPools []v1alpha2.InClusterIPPool) (netip.Prefix, error) {
+	poolIPSet, err := poolutil.PoolSpecToIPSet(&pool.Spec)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	subPoolIPSet, err := poolutil.SubPoolsToIPSet(subPools)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	inUseIPSet, err := r.addressesInUse(ctx, pool)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	builder := &netipx.IPSetBuilder{}
+	builder.AddSet(poolIPSet)
+	builder.RemoveSet(subPoolIPSet)
+	builder.RemoveSet(inUseIPSet)
+	freeIPSet, err := builder.IPSet()
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return poolutil.FindFreePrefix(freeIPSet, pool.Spec.ClusterSubPools.Prefix)
+}
+
+// addressesInUse returns the addresses allocated from the pool. They are read
+// through the APIReader, as the cache may miss addresses that were just
+// allocated.
+func (r *ClusterSubPoolReconciler) addressesInUse(ctx context.Context, pool *v1alpha2.GlobalInClusterIPPool) (*netipx.IPSet, error) {
+	addresses := &ipamv1.IPAddressList{}
+	if err := r.APIReader.List(ctx, addresses); err != nil {
+		return nil, errors.Wrap(err, "failed to list IPAddresses")
+	}
+
+	builder := &netipx.IPSetBuilder{}
+	for _, address := range addresses.Items {
+		poolRef := address.Spec.PoolRef
+		if poolRef.APIGroup == nil || *poolRef.APIGroup != v1alpha2.GroupVersion.Group || poolRef.Kind != globalInClusterIPPoolKind || poolRef.Name != pool.Name {
+			continue
+		}
+		if ip, err := netip.ParseAddr(address.Spec.Address); err == nil {
+			builder.Add(ip)
+		}
+	}
+	return builder.IPSet()
+}
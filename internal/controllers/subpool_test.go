@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("ClusterSubPoolReconciler", func() {
+	const poolName = "test-supernet"
+
+	var namespace string
+
+	BeforeEach(func() {
+		namespace = createNamespace()
+
+		pool := v1alpha2.GlobalInClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: poolName,
+			},
+			Spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/24"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				ClusterSubPools: &v1alpha2.ClusterSubPools{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"ipam": "carve"}},
+					Prefix:          28,
+				},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &pool)).To(Succeed())
+		Eventually(Get(&pool)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		// Sub-pools are deleted once their clusters are gone.
+		Eventually(ObjectList(&v1alpha2.InClusterIPPoolList{}, client.MatchingLabels{v1alpha2.SubPoolOfLabel: poolName})).
+			Should(HaveField("Items", BeEmpty()))
+		deleteClusterScopedPool(poolName)
+	})
+
+	createCluster := func(name string, labels map[string]string) {
+		cluster := clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    labels,
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &cluster)).To(Succeed())
+	}
+
+	subPool := func(clusterName string) *v1alpha2.InClusterIPPool {
+		return &v1alpha2.InClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      clusterName + "-" + poolName,
+			},
+		}
+	}
+
+	It("should carve a sub-pool out of the pool for every matching cluster", func() {
+		createCluster("cluster-a", map[string]string{"ipam": "carve"})
+		createCluster("cluster-b", map[string]string{"ipam": "carve"})
+		createCluster("cluster-c", nil)
+
+		Eventually(Object(subPool("cluster-a"))).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(And(
+			HaveField("Spec.Addresses", Equal([]string{"10.0.0.16/28"})),
+			HaveField("Spec.Prefix", Equal(24)),
+			HaveField("Spec.Gateway", Equal("10.0.0.1")),
+			HaveField("ObjectMeta.Labels", HaveKeyWithValue(v1alpha2.SubPoolOfLabel, poolName)),
+			HaveField("ObjectMeta.OwnerReferences", ContainElement(HaveField("Name", Equal("cluster-a")))),
+			HaveField("Spec.OwnerCluster.Name", Equal("cluster-a")),
+		))
+		Eventually(Object(subPool("cluster-b"))).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Addresses", Equal([]string{"10.0.0.32/28"})))
+		Consistently(Get(subPool("cluster-c"))).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+
+		pool := v1alpha2.GlobalInClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: poolName}}
+		Eventually(Object(&pool)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(And(
+			HaveField("Status.SubPools", ConsistOf(
+				v1alpha2.SubPool{Namespace: namespace, Name: "cluster-a-" + poolName, ClusterName: "cluster-a", Addresses: "10.0.0.16/28"},
+				v1alpha2.SubPool{Namespace: namespace, Name: "cluster-b-" + poolName, ClusterName: "cluster-b", Addresses: "10.0.0.32/28"},
+			)),
+			HaveField("Status.Addresses.Free", Equal(221)),
+		))
+
+		claim := newClaim("test", namespace, "GlobalInClusterIPPool", poolName)
+		Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+		Eventually(findAddress("test", namespace)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Spec.Address", Equal("10.0.0.2")))
+		deleteClaim("test", namespace)
+
+		deleteCluster("cluster-a", namespace)
+		Eventually(Get(subPool("cluster-a"))).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).ShouldNot(Succeed())
+
+		Eventually(Object(&pool)).
+			WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(
+			HaveField("Status.SubPools", ConsistOf(HaveField("ClusterName", Equal("cluster-b")))))
+
+		deleteCluster("cluster-b", namespace)
+		deleteCluster("cluster-c", namespace)
+	})
+
+	It("should never carve a sub-pool out of addresses that are allocated at the same time", func() {
+		clusterNames := []string{"cluster-a", "cluster-b", "cluster-c"}
+		claimNames := []string{"test-1", "test-2", "test-3", "test-4", "test-5", "test-6", "test-7", "test-8"}
+		for i := range claimNames {
+			claim := newClaim(claimNames[i], namespace, "GlobalInClusterIPPool", poolName)
+			Expect(k8sClient.Create(context.Background(), &claim)).To(Succeed())
+			if i < len(clusterNames) {
+				createCluster(clusterNames[i], map[string]string{"ipam": "carve"})
+			}
+		}
+
+		var subPools []*v1alpha2.InClusterIPPool
+		for _, clusterName := range clusterNames {
+			p := subPool(clusterName)
+			Eventually(Get(p)).WithTimeout(time.Second).WithPolling(100 * time.Millisecond).Should(Succeed())
+			subPools = append(subPools, p)
+		}
+		for _, claimName := range claimNames {
+			address := ipamv1.IPAddress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: claimName}}
+			Eventually(Get(&address)).WithTimeout(5 * time.Second).WithPolling(100 * time.Millisecond).Should(Succeed())
+			for _, p := range subPools {
+				prefix := netip.MustParsePrefix(p.Spec.Addresses[0])
+				Expect(prefix.Contains(netip.MustParseAddr(address.Spec.Address))).To(BeFalse(),
+					"address %s of claim %s is part of sub-pool %s", address.Spec.Address, claimName, p.Name)
+			}
+		}
+
+		for _, claimName := range claimNames {
+			deleteClaim(claimName, namespace)
+		}
+		for _, clusterName := range clusterNames {
+			deleteCluster(clusterName, namespace)
+		}
+	})
+})
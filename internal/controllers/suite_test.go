@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,6 +44,10 @@ import (
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/ipamutil"
 )
 
+// orphanedAddressGracePeriod is kept short so that orphaned addresses are
+// released within the timeouts used by the tests.
+const orphanedAddressGracePeriod = 2 * time.Second
+
 // These tests use Ginkgo (BDD-style Go testing framework). Refer to
 // http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
 
@@ -97,23 +102,53 @@ var _ = BeforeSuite(func() {
 
 	Expect(index.SetupIndexes(ctx, mgr)).To(Succeed())
 
+	subPoolLock := &sync.Mutex{}
+
 	Expect(
 		(&ipamutil.ClaimReconciler{
-			Client:  mgr.GetClient(),
-			Scheme:  mgr.GetScheme(),
-			Adapter: &InClusterProviderAdapter{Client: mgr.GetClient()},
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Adapter: &InClusterProviderAdapter{
+				Client:      mgr.GetClient(),
+				APIReader:   mgr.GetAPIReader(),
+				SubPoolLock: subPoolLock,
+			},
 		}).SetupWithManager(ctx, mgr),
 	).To(Succeed())
 
 	Expect(
 		(&InClusterIPPoolReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("inclusterippool-controller"),
 		}).SetupWithManager(ctx, mgr),
 	).To(Succeed())
 
 	Expect(
 		(&GlobalInClusterIPPoolReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("globalinclusterippool-controller"),
+		}).SetupWithManager(ctx, mgr),
+	).To(Succeed())
+
+	Expect(
+		(&OrphanedIPAddressReconciler{
+			Client:      mgr.GetClient(),
+			GracePeriod: orphanedAddressGracePeriod,
+		}).SetupWithManager(ctx, mgr),
+	).To(Succeed())
+
+	Expect(
+		(&ClusterSubPoolReconciler{
+			Client:      mgr.GetClient(),
+			APIReader:   mgr.GetAPIReader(),
+			SubPoolLock: subPoolLock,
+		}).SetupWithManager(ctx, mgr),
+	).To(Succeed())
+
+	Expect(
+		(&PoolMigrationReconciler{
 			Client: mgr.GetClient(),
 			Scheme: mgr.GetScheme(),
 		}).SetupWithManager(ctx, mgr),
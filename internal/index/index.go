@@ -47,7 +47,7 @@ func SetupIndexes(ctx context.Context, mgr manager.Manager) error {
 
 	return mgr.GetCache().IndexField(ctx, &ipamv1.IPAddressClaim{},
 		IPAddressClaimPoolRefCombinedField,
-		ipAddressClaimByCombinedPoolRef,
+		IPAddressClaimByCombinedPoolRef,
 	)
 }
 
@@ -60,7 +60,8 @@ func IPAddressByCombinedPoolRef(o client.Object) []string {
 	return []string{IPPoolRefValue(ip.Spec.PoolRef)}
 }
 
-func ipAddressClaimByCombinedPoolRef(o client.Object) []string {
+// IPAddressClaimByCombinedPoolRef fulfills the IndexerFunc for IPAddressClaim poolRefs.
+func IPAddressClaimByCombinedPoolRef(o client.Object) []string {
 	ip, ok := o.(*ipamv1.IPAddressClaim)
 	if !ok {
 		panic(fmt.Sprintf("Expected an IPAddressClaim but got a %T", o))
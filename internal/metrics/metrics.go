@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains the Prometheus metrics exposed by the provider.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+const (
+	poolKindLabel      = "pool_kind"
+	poolNamespaceLabel = "pool_namespace"
+	poolNameLabel      = "pool_name"
+	limitLabel         = "limit"
+	stateLabel         = "state"
+)
+
+var (
+	// OrphanedAddresses reports the number of orphaned IPAddresses per pool.
+	OrphanedAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_ipam_incluster_orphaned_ipaddresses",
+			Help: "Number of IPAddresses allocated from a pool whose IPAddressClaim or Cluster no longer exists.",
+		},
+		[]string{poolKindLabel, poolNamespaceLabel, poolNameLabel},
+	)
+
+	// ReleasedOrphanedAddresses counts the orphaned IPAddresses that have been released per pool.
+	ReleasedOrphanedAddresses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_ipam_incluster_released_orphaned_ipaddresses_total",
+			Help: "Total number of orphaned IPAddresses released by the garbage collector.",
+		},
+		[]string{poolKindLabel, poolNamespaceLabel, poolNameLabel},
+	)
+
+	// RateLimitedAllocations counts the allocations delayed by a rate limit
+	// per pool and limit.
+	RateLimitedAllocations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_ipam_incluster_rate_limited_allocations_total",
+			Help: "Total number of allocations delayed because a rate limit of the pool was exceeded.",
+		},
+		[]string{poolKindLabel, poolNamespaceLabel, poolNameLabel, limitLabel},
+	)
+
+	// PoolAddresses reports the number of addresses per pool and state, as
+	// reported in the status of the pool.
+	PoolAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_ipam_incluster_pool_ipaddresses",
+			Help: "Number of IPs of a pool by state: total, used, free, reserved, retiring, or out_of_range.",
+		},
+		[]string{poolKindLabel, poolNamespaceLabel, poolNameLabel, stateLabel},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		OrphanedAddresses,
+		ReleasedOrphanedAddresses,
+		RateLimitedAllocations,
+		PoolAddresses,
+	)
+}
+
+// PoolLabels returns the metric labels identifying a pool.
+func PoolLabels(kind, namespace, name string) prometheus.Labels {
+	return prometheus.Labels{
+		poolKindLabel:      kind,
+		poolNamespaceLabel: namespace,
+		poolNameLabel:      name,
+	}
+}
+
+// DeletePool removes all per pool metrics of the given pool.
+func DeletePool(kind, namespace, name string) {
+	labels := PoolLabels(kind, namespace, name)
+	OrphanedAddresses.Delete(labels)
+	ReleasedOrphanedAddresses.Delete(labels)
+	RateLimitedAllocations.DeletePartialMatch(labels)
+	PoolAddresses.DeletePartialMatch(labels)
+}
+
+// RateLimitLabels returns the metric labels identifying a rate limit of a
+// pool, either "pool" or "cluster".
+func RateLimitLabels(kind, namespace, name, limit string) prometheus.Labels {
+	labels := PoolLabels(kind, namespace, name)
+	labels[limitLabel] = limit
+	return labels
+}
+
+// SetPoolAddresses reports the address counts of a pool.
+func SetPoolAddresses(kind, namespace, name string, addresses *v1alpha2.InClusterIPPoolStatusIPAddresses) {
+	for state, count := range map[string]int{
+		"total":        addresses.Total,
+		"used":         addresses.Used,
+		"free":         addresses.Free,
+		"reserved":     addresses.Reserved,
+		"retiring":     addresses.Retiring,
+		"out_of_range": addresses.OutOfRange,
+	} {
+		labels := PoolLabels(kind, namespace, name)
+		labels[stateLabel] = state
+		PoolAddresses.With(labels).Set(float64(count))
+	}
+}
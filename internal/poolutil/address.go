@@ -0,0 +1,215 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"go4.org/netipx"
+)
+
+// ParseAddressRange parses an address of a pool into the range of IPs it
+// describes. An address is one of:
+//   - a single IP, e.g. 10.0.0.1
+//   - a CIDR, e.g. 10.0.0.0/24, or for IPv4 with a netmask, e.g.
+//     10.0.0.0/255.255.255.0
+//   - a hyphenated range, e.g. 10.0.0.10-10.0.0.50. The end may be shortened
+//     to the trailing octets or hextets that differ from the start, e.g.
+//     10.0.0.10-50 or fd01::1:10-2:ff
+//   - offsets within a CIDR, e.g. 10.0.0.0/24[10-200] for the 10th through
+//     the 200th address of 10.0.0.0/24, or 10.0.0.0/24[10] for a single one
+func ParseAddressRange(addressStr string) (netipx.IPRange, error) {
+	switch {
+	case strings.HasSuffix(addressStr, "]"):
+		return parseOffsetRange(addressStr)
+	case strings.Contains(addressStr, "-"):
+		return parseHyphenatedRange(addressStr)
+	case strings.Contains(addressStr, "/"):
+		prefix, err := parsePrefix(addressStr)
+		if err != nil {
+			return netipx.IPRange{}, err
+		}
+		return netipx.RangeOfPrefix(prefix), nil
+	default:
+		addr, err := parseAddr(addressStr)
+		if err != nil {
+			return netipx.IPRange{}, err
+		}
+		return netipx.IPRangeFrom(addr, addr), nil
+	}
+}
+
+// ParseAddressPrefix returns the CIDR of an address that is a CIDR, with or
+// without offsets.
+func ParseAddressPrefix(addressStr string) (netip.Prefix, error) {
+	if i := strings.LastIndex(addressStr, "["); i >= 0 && strings.HasSuffix(addressStr, "]") {
+		addressStr = addressStr[:i]
+	}
+	if !strings.Contains(addressStr, "/") {
+		return netip.Prefix{}, fmt.Errorf("%q is not a CIDR", addressStr)
+	}
+	return parsePrefix(addressStr)
+}
+
+func parseAddr(addrStr string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(addrStr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if addr.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("IP %s must not have a zone", addr)
+	}
+	return addr, nil
+}
+
+func parsePrefix(prefixStr string) (netip.Prefix, error) {
+	addrStr, maskStr, _ := strings.Cut(prefixStr, "/")
+	if !strings.Contains(maskStr, ".") {
+		return netip.ParsePrefix(prefixStr)
+	}
+
+	addr, err := parseAddr(addrStr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	mask, err := netip.ParseAddr(maskStr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid netmask %q: %w", maskStr, err)
+	}
+	if !addr.Is4() || !mask.Is4() {
+		return netip.Prefix{}, fmt.Errorf("netmasks are only supported for IPv4")
+	}
+	bits, size := net.IPMask(mask.AsSlice()).Size()
+	if size == 0 {
+		return netip.Prefix{}, fmt.Errorf("netmask %s is not contiguous", mask)
+	}
+	return netip.PrefixFrom(addr, bits), nil
+}
+
+func parseHyphenatedRange(rangeStr string) (netipx.IPRange, error) {
+	fromStr, toStr, _ := strings.Cut(rangeStr, "-")
+	from, err := parseAddr(fromStr)
+	if err != nil {
+		return netipx.IPRange{}, fmt.Errorf("invalid range start: %w", err)
+	}
+	to, err := parseAddr(toStr)
+	if err != nil {
+		if to, err = expandSuffix(from, toStr); err != nil {
+			return netipx.IPRange{}, err
+		}
+	}
+
+	if from.Is4() != to.Is4() {
+		return netipx.IPRange{}, fmt.Errorf("range start %s and end %s are of mixed IP families", from, to)
+	}
+	if to.Less(from) {
+		return netipx.IPRange{}, fmt.Errorf("range start %s is after range end %s", from, to)
+	}
+	return netipx.IPRangeFrom(from, to), nil
+}
+
+// expandSuffix replaces the trailing octets or hextets of addr with the ones
+// of the suffix.
+func expandSuffix(addr netip.Addr, suffix string) (netip.Addr, error) {
+	invalid := fmt.Errorf("range end %q is neither an IP nor a suffix of %s", suffix, addr)
+
+	if addr.Is4() {
+		parts := strings.Split(suffix, ".")
+		if len(parts) > 3 {
+			return netip.Addr{}, invalid
+		}
+		b := addr.As4()
+		for i, part := range parts {
+			octet, err := strconv.ParseUint(part, 10, 8)
+			if err != nil || strconv.FormatUint(octet, 10) != part {
+				return netip.Addr{}, invalid
+			}
+			b[4-len(parts)+i] = byte(octet)
+		}
+		return netip.AddrFrom4(b), nil
+	}
+
+	parts := strings.Split(suffix, ":")
+	if len(parts) > 7 {
+		return netip.Addr{}, invalid
+	}
+	b := addr.As16()
+	for i, part := range parts {
+		if part == "" || len(part) > 4 {
+			return netip.Addr{}, invalid
+		}
+		hextet, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return netip.Addr{}, invalid
+		}
+		j := 2 * (8 - len(parts) + i)
+		b[j], b[j+1] = byte(hextet>>8), byte(hextet)
+	}
+	return netip.AddrFrom16(b), nil
+}
+
+func parseOffsetRange(offsetStr string) (netipx.IPRange, error) {
+	i := strings.LastIndex(offsetStr, "[")
+	if i < 0 {
+		return netipx.IPRange{}, fmt.Errorf("offsets must be enclosed in brackets")
+	}
+	prefix, err := ParseAddressPrefix(offsetStr)
+	if err != nil {
+		return netipx.IPRange{}, err
+	}
+
+	fromStr, toStr, isRange := strings.Cut(offsetStr[i+1:len(offsetStr)-1], "-")
+	from, err := strconv.ParseUint(fromStr, 10, 64)
+	if err != nil {
+		return netipx.IPRange{}, fmt.Errorf("invalid offset %q", fromStr)
+	}
+	to := from
+	if isRange {
+		if to, err = strconv.ParseUint(toStr, 10, 64); err != nil {
+			return netipx.IPRange{}, fmt.Errorf("invalid offset %q", toStr)
+		}
+	}
+	if to < from {
+		return netipx.IPRange{}, fmt.Errorf("offset %d is after offset %d", from, to)
+	}
+	prefix = prefix.Masked()
+	if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits < 64 && to >= 1<<hostBits {
+		return netipx.IPRange{}, fmt.Errorf("offset %d is outside of %s", to, prefix)
+	}
+
+	return netipx.IPRangeFrom(addOffset(prefix.Addr(), from), addOffset(prefix.Addr(), to)), nil
+}
+
+// addOffset returns the address offset addresses after addr. The caller must
+// ensure that the result does not overflow.
+func addOffset(addr netip.Addr, offset uint64) netip.Addr {
+	b := addr.As16()
+	carry := offset
+	for i := len(b) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(b[i]) + carry&0xff
+		b[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	if addr.Is4() {
+		return netip.AddrFrom16(b).Unmap()
+	}
+	return netip.AddrFrom16(b)
+}
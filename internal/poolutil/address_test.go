@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseAddressRange", func() {
+	DescribeTable("parses the range of an address",
+		func(address, from, to string) {
+			addrRange, err := ParseAddressRange(address)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addrRange.From().String()).To(Equal(from))
+			Expect(addrRange.To().String()).To(Equal(to))
+		},
+		Entry("single IPv4 address", "10.0.0.1", "10.0.0.1", "10.0.0.1"),
+		Entry("single IPv6 address", "fd01::1", "fd01::1", "fd01::1"),
+		Entry("IPv4 CIDR", "10.0.0.0/24", "10.0.0.0", "10.0.0.255"),
+		Entry("IPv6 CIDR", "fd01::/120", "fd01::", "fd01::ff"),
+		Entry("IPv4 range", "10.0.0.10-10.0.1.50", "10.0.0.10", "10.0.1.50"),
+		Entry("IPv6 range", "fd01::10-fd01::1:ff", "fd01::10", "fd01::1:ff"),
+		Entry("netmask", "10.0.0.0/255.255.255.0", "10.0.0.0", "10.0.0.255"),
+		Entry("unaligned netmask", "10.0.0.5/255.255.255.252", "10.0.0.4", "10.0.0.7"),
+		Entry("IPv4 range with a shortened end", "10.0.0.10-50", "10.0.0.10", "10.0.0.50"),
+		Entry("IPv4 range with a shortened end of two octets", "10.0.0.10-1.50", "10.0.0.10", "10.0.1.50"),
+		Entry("IPv6 range with a suffix", "fd01::10-ff", "fd01::10", "fd01::ff"),
+		Entry("IPv6 range with a suffix of two hextets", "fd01::1:10-2:ff", "fd01::1:10", "fd01::2:ff"),
+		Entry("offsets within an IPv4 CIDR", "10.0.0.0/24[10-200]", "10.0.0.10", "10.0.0.200"),
+		Entry("offsets crossing octets", "10.0.0.0/16[250-260]", "10.0.0.250", "10.0.1.4"),
+		Entry("single offset", "10.0.0.0/24[5]", "10.0.0.5", "10.0.0.5"),
+		Entry("offsets within a netmask", "10.0.0.0/255.255.255.0[10-20]", "10.0.0.10", "10.0.0.20"),
+		Entry("offsets within an IPv6 CIDR", "fd01::/64[256-511]", "fd01::100", "fd01::1ff"),
+		Entry("offsets within a large IPv6 CIDR", "fd01::/32[1-2]", "fd01::1", "fd01::2"),
+	)
+
+	DescribeTable("reports why an address is invalid",
+		func(address, reason string) {
+			_, err := ParseAddressRange(address)
+			Expect(err).To(MatchError(ContainSubstring(reason)))
+		},
+		Entry("invalid IP", "10.0.0.256", "IPv4 field has value >255"),
+		Entry("IP with a zone", "fe80::1%eth0", "must not have a zone"),
+		Entry("range out of order", "10.0.0.50-10.0.0.10", "range start 10.0.0.50 is after range end 10.0.0.10"),
+		Entry("shortened range out of order", "10.0.0.50-10", "range start 10.0.0.50 is after range end 10.0.0.10"),
+		Entry("range of mixed families", "10.0.0.1-fd01::1", "mixed IP families"),
+		Entry("invalid range start", "10.0.0-10.0.0.5", "invalid range start"),
+		Entry("invalid shortened end", "10.0.0.10-256", `range end "256" is neither an IP nor a suffix of 10.0.0.10`),
+		Entry("shortened end with leading zeros", "10.0.0.10-050", "is neither an IP nor a suffix"),
+		Entry("IPv6 suffix with an empty hextet", "fd01::10-1:", "is neither an IP nor a suffix"),
+		Entry("invalid prefix length", "10.0.0.0/33", "prefix length out of range"),
+		Entry("non-contiguous netmask", "10.0.0.0/255.0.255.0", "netmask 255.0.255.0 is not contiguous"),
+		Entry("IPv6 netmask", "fd01::/255.255.255.0", "netmasks are only supported for IPv4"),
+		Entry("offsets without a CIDR", "10.0.0.1[5]", "is not a CIDR"),
+		Entry("offsets out of order", "10.0.0.0/24[20-10]", "offset 20 is after offset 10"),
+		Entry("offset outside of the CIDR", "10.0.0.0/24[10-256]", "offset 256 is outside of 10.0.0.0/24"),
+		Entry("invalid offset", "10.0.0.0/24[a-10]", `invalid offset "a"`),
+	)
+})
+
+var _ = Describe("ParseAddressPrefix", func() {
+	It("returns the CIDR of an address", func() {
+		Expect(ParseAddressPrefix("10.0.0.0/24")).To(HaveField("String()", "10.0.0.0/24"))
+		Expect(ParseAddressPrefix("10.0.0.0/255.255.0.0")).To(HaveField("String()", "10.0.0.0/16"))
+		Expect(ParseAddressPrefix("fd01::/64[10-20]")).To(HaveField("String()", "fd01::/64"))
+	})
+
+	It("returns an error for addresses that are no CIDR", func() {
+		_, err := ParseAddressPrefix("10.0.0.1-10.0.0.5")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"context"
+
+	"go4.org/netipx"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	pooltypes "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/types"
+)
+
+// PoolKind returns the kind of an InClusterIPPool or GlobalInClusterIPPool.
+// Unlike the GroupVersionKind of the object, it does not depend on the
+// TypeMeta being set.
+func PoolKind(pool client.Object) string {
+	switch pool.(type) {
+	case *v1alpha2.InClusterIPPool:
+		return "InClusterIPPool"
+	case *v1alpha2.GlobalInClusterIPPool:
+		return "GlobalInClusterIPPool"
+	default:
+		return pool.GetObjectKind().GroupVersionKind().Kind
+	}
+}
+
+// PoolRef returns a reference to an InClusterIPPool or GlobalInClusterIPPool,
+// as used in the poolRef of IPAddresses.
+func PoolRef(pool client.Object) corev1.TypedLocalObjectReference {
+	return corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(v1alpha2.GroupVersion.Group),
+		Kind:     PoolKind(pool),
+		Name:     pool.GetName(),
+	}
+}
+
+func isSamePool(a, b client.Object) bool {
+	return PoolKind(a) == PoolKind(b) && a.GetNamespace() == b.GetNamespace() && a.GetName() == b.GetName()
+}
+
+// ListPools fetches all InClusterIPPools and GlobalInClusterIPPools.
+func ListPools(ctx context.Context, c client.Reader) ([]pooltypes.GenericInClusterPool, error) {
+	namespacedPools := &v1alpha2.InClusterIPPoolList{}
+	if err := c.List(ctx, namespacedPools); err != nil {
+		return nil, err
+	}
+	globalPools := &v1alpha2.GlobalInClusterIPPoolList{}
+	if err := c.List(ctx, globalPools); err != nil {
+		return nil, err
+	}
+
+	pools := make([]pooltypes.GenericInClusterPool, 0, len(namespacedPools.Items)+len(globalPools.Items))
+	for i := range namespacedPools.Items {
+		pools = append(pools, &namespacedPools.Items[i])
+	}
+	for i := range globalPools.Items {
+		pools = append(pools, &globalPools.Items[i])
+	}
+	return pools, nil
+}
+
+// OverlappingPools returns all pools other than the given pool whose addresses
+// overlap with ipSet.
+func OverlappingPools(ctx context.Context, c client.Reader, pool client.Object, ipSet *netipx.IPSet) ([]pooltypes.GenericInClusterPool, error) {
+	pools, err := ListPools(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlapping []pooltypes.GenericInClusterPool
+	for _, other := range pools {
+		if isSamePool(pool, other) {
+			continue
+		}
+		otherIPSet, err := PoolSpecToIPSet(other.PoolSpec())
+		if err != nil {
+			// Pools are validated by the webhook, a broken pool cannot hand out addresses anyway.
+			continue
+		}
+		if ipSet.Overlaps(otherIPSet) {
+			overlapping = append(overlapping, other)
+		}
+	}
+	return overlapping, nil
+}
+
+// ListAddressesOfOverlappingPools fetches the IPAddresses of all pools other
+// than the given pool whose addresses overlap with ipSet. Pools that overlap
+// without allowOverlap, e.g. because they were created before overlapping
+// pools were rejected, are included as well.
+// Note: requires `index.ipAddressByCombinedPoolRef` to be set up.
+func ListAddressesOfOverlappingPools(ctx context.Context, c client.Reader, pool client.Object, ipSet *netipx.IPSet) ([]ipamv1.IPAddress, error) {
+	overlapping, err := OverlappingPools(ctx, c, pool, ipSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []ipamv1.IPAddress
+	for _, other := range overlapping {
+		otherAddresses, err := ListAddressesInUse(ctx, c, other.GetNamespace(), PoolRef(other))
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, otherAddresses...)
+	}
+	return addresses, nil
+}
+
+// ListAddressesOfGatewayPools fetches the IPAddresses of all pools other than
+// the given pool whose addresses contain the gateway, or which share it. Any
+// of them may have allocated the gateway.
+// Note: requires `index.ipAddressByCombinedPoolRef` to be set up.
+func ListAddressesOfGatewayPools(ctx context.Context, c client.Reader, pool client.Object, gatewayIPSet *netipx.IPSet) ([]ipamv1.IPAddress, error) {
+	pools, err := ListPools(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []ipamv1.IPAddress
+	for _, other := range pools {
+		if isSamePool(pool, other) {
+			continue
+		}
+		otherIPSet, err := PoolSpecToIPSet(other.PoolSpec())
+		if err != nil {
+			continue
+		}
+		otherGatewayIPSet, err := GatewayIPSet(other.PoolSpec())
+		if err != nil {
+			continue
+		}
+		if !gatewayIPSet.Overlaps(otherIPSet) && !gatewayIPSet.Overlaps(otherGatewayIPSet) {
+			continue
+		}
+		otherAddresses, err := ListAddressesInUse(ctx, c, other.GetNamespace(), PoolRef(other))
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, otherAddresses...)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"context"
+	"fmt"
+
+	"go4.org/netipx"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	pooltypes "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/pkg/types"
+)
+
+// IsChildOf reports whether pool references parent as its parent.
+func IsChildOf(pool pooltypes.GenericInClusterPool, parent client.Object) bool {
+	ref := pool.PoolSpec().ParentRef
+	if ref == nil || ref.Kind != PoolKind(parent) || ref.Name != parent.GetName() {
+		return false
+	}
+	// InClusterIPPools can only be the parent of pools in the same namespace.
+	return parent.GetNamespace() == "" || parent.GetNamespace() == pool.GetNamespace()
+}
+
+// GetParentPool fetches the parent of a pool. The namespace of an
+// InClusterIPPool parent is the namespace of the pool. Sub-pools are children
+// of the GlobalInClusterIPPool they were carved out of. It returns nil if the
+// pool has no parent.
+func GetParentPool(ctx context.Context, c client.Reader, pool pooltypes.GenericInClusterPool) (pooltypes.GenericInClusterPool, error) {
+	ref := pool.PoolSpec().ParentRef
+	if ref == nil {
+		if _, ok := pool.(*v1alpha2.InClusterIPPool); !ok {
+			return nil, nil
+		}
+		parentName, ok := pool.GetLabels()[v1alpha2.SubPoolOfLabel]
+		if !ok {
+			return nil, nil
+		}
+		ref = &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: parentName}
+	}
+
+	var parent pooltypes.GenericInClusterPool
+	key := client.ObjectKey{Name: ref.Name}
+	switch ref.Kind {
+	case "InClusterIPPool":
+		parent = &v1alpha2.InClusterIPPool{}
+		key.Namespace = pool.GetNamespace()
+	case "GlobalInClusterIPPool":
+		parent = &v1alpha2.GlobalInClusterIPPool{}
+	default:
+		return nil, fmt.Errorf("unsupported parent pool kind %q", ref.Kind)
+	}
+
+	if err := c.Get(ctx, key, parent); err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// Ancestors fetches the parent of a pool, the parent of the parent and so on.
+// Parents that don't exist end the hierarchy. If the hierarchy forms a cycle,
+// the last ancestor is the first pool that was seen twice.
+func Ancestors(ctx context.Context, c client.Reader, pool pooltypes.GenericInClusterPool) ([]pooltypes.GenericInClusterPool, error) {
+	var ancestors []pooltypes.GenericInClusterPool
+	for current := pool; ; {
+		parent, err := GetParentPool(ctx, c, current)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return ancestors, nil
+			}
+			return nil, err
+		}
+		if parent == nil {
+			return ancestors, nil
+		}
+		ancestors = append(ancestors, parent)
+		if ContainsPool(append([]pooltypes.GenericInClusterPool{pool}, ancestors[:len(ancestors)-1]...), parent) {
+			return ancestors, nil
+		}
+		current = parent
+	}
+}
+
+// ContainsPool reports whether pools contains the given pool.
+func ContainsPool(pools []pooltypes.GenericInClusterPool, pool client.Object) bool {
+	for _, p := range pools {
+		if isSamePool(p, pool) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListChildPools fetches all pools that have the given pool as parent.
+func ListChildPools(ctx context.Context, c client.Reader, parent client.Object) ([]pooltypes.GenericInClusterPool, error) {
+	pools, err := ListPools(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []pooltypes.GenericInClusterPool
+	for _, pool := range pools {
+		if IsChildOf(pool, parent) {
+			children = append(children, pool)
+		}
+	}
+	return children, nil
+}
+
+// ChildPoolsToIPSet returns an IPSet of the addresses of all child pools.
+func ChildPoolsToIPSet(children []pooltypes.GenericInClusterPool) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, child := range children {
+		ipSet, err := PoolSpecToIPSet(child.PoolSpec())
+		if err != nil {
+			return nil, err
+		}
+		builder.AddSet(ipSet)
+	}
+	return builder.IPSet()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("IsChildOf", func() {
+	child := func(namespace string, ref *v1alpha2.PoolReference) *v1alpha2.InClusterIPPool {
+		return &v1alpha2.InClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "child"},
+			Spec:       v1alpha2.InClusterIPPoolSpec{ParentRef: ref},
+		}
+	}
+	namespacedParent := &v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "parent"}}
+	globalParent := &v1alpha2.GlobalInClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: "parent"}}
+
+	It("matches an InClusterIPPool parent in the same namespace", func() {
+		ref := &v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "parent"}
+		Expect(IsChildOf(child("default", ref), namespacedParent)).To(BeTrue())
+		Expect(IsChildOf(child("other", ref), namespacedParent)).To(BeFalse())
+		Expect(IsChildOf(child("default", ref), globalParent)).To(BeFalse())
+	})
+
+	It("matches a GlobalInClusterIPPool parent from any namespace", func() {
+		ref := &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: "parent"}
+		Expect(IsChildOf(child("other", ref), globalParent)).To(BeTrue())
+		Expect(IsChildOf(child("default", ref), namespacedParent)).To(BeFalse())
+	})
+
+	It("does not match pools without a parent", func() {
+		Expect(IsChildOf(child("default", nil), namespacedParent)).To(BeFalse())
+		Expect(IsChildOf(child("default", nil), globalParent)).To(BeFalse())
+	})
+})
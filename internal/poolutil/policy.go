@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"fmt"
+	"net/netip"
+
+	"go4.org/netipx"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+const (
+	// octetBoundaryBits is the size of the blocks whose first and last
+	// address are reserved by the OctetBoundaries policy.
+	octetBoundaryBits = 24
+
+	// subnetAnycastBits is the size of the blocks whose highest 128 addresses
+	// are reserved by the SubnetAnycast policy.
+	subnetAnycastBits = 64
+
+	// MaxPolicyBlockBits limits the number of blocks the OctetBoundaries and
+	// SubnetAnycast policies are applied to, to 2^MaxPolicyBlockBits.
+	MaxPolicyBlockBits = 16
+)
+
+// ReservesSubnetBoundaries returns whether the network and broadcast address
+// of the subnet of a pool, or its anycast address for IPv6, are reserved.
+func ReservesSubnetBoundaries(poolSpec *v1alpha2.InClusterIPPoolSpec) bool {
+	if policy := poolSpec.ReservedAddressPolicy; policy != nil && policy.SubnetBoundaries != nil {
+		return *policy.SubnetBoundaries
+	}
+	return !poolSpec.AllocateReservedIPAddresses
+}
+
+// PolicyBlockBits returns the prefix length of the blocks the OctetBoundaries
+// or SubnetAnycast policy is applied to for an address family, and whether
+// the policy applies to the family at all.
+func PolicyBlockBits(policy *v1alpha2.ReservedAddressPolicy, is4 bool) (int, bool) {
+	switch {
+	case is4 && policy.OctetBoundaries:
+		return octetBoundaryBits, true
+	case !is4 && policy.SubnetAnycast:
+		return subnetAnycastBits, true
+	default:
+		return 0, false
+	}
+}
+
+// ReservedByPolicyIPSet returns the addresses of addressesIPSet that are
+// reserved by the reserved address policy of a pool with the given subnet.
+func ReservedByPolicyIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec, subnet netip.Prefix, addressesIPSet *netipx.IPSet) (*netipx.IPSet, error) {
+	if !subnet.IsValid() {
+		return &netipx.IPSet{}, nil
+	}
+	subnet = subnet.Masked()
+	builder := &netipx.IPSetBuilder{}
+
+	if ReservesSubnetBoundaries(poolSpec) && !IsPointToPoint(subnet) {
+		subnetRange := netipx.RangeOfPrefix(subnet)
+		builder.Add(subnetRange.From()) // network addr in IPv4, anycast addr in IPv6
+		if subnet.Addr().Is4() {
+			builder.Add(subnetRange.To()) // broadcast addr
+		}
+	}
+
+	if policy := poolSpec.ReservedAddressPolicy; policy != nil {
+		if policy.FirstAddresses > 0 {
+			from := subnet.Addr().Next()
+			to := from
+			for i := 1; i < policy.FirstAddresses && subnet.Contains(to.Next()); i++ {
+				to = to.Next()
+			}
+			if subnet.Contains(from) {
+				builder.AddRange(netipx.IPRangeFrom(from, to))
+			}
+		}
+
+		if bits, ok := PolicyBlockBits(policy, subnet.Addr().Is4()); ok && (subnet.Addr().Is6() || subnet.Bits() < bits) {
+			err := forEachBlock(addressesIPSet, bits, func(block netip.Prefix) {
+				blockRange := netipx.RangeOfPrefix(block)
+				if subnet.Addr().Is4() {
+					builder.Add(blockRange.From())
+					builder.Add(blockRange.To())
+					return
+				}
+				last := blockRange.To().As16()
+				first := last
+				first[15] = 0x80
+				builder.AddRange(netipx.IPRangeFrom(netip.AddrFrom16(first), netip.AddrFrom16(last)))
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	builder.Intersect(addressesIPSet)
+	return builder.IPSet()
+}
+
+// forEachBlock calls fn once for every block of the given prefix length that
+// overlaps with the IPSet. It fails once more than 2^MaxPolicyBlockBits blocks
+// are visited, so that pools which bypassed the validation of the webhook
+// cannot stall the caller.
+func forEachBlock(ipSet *netipx.IPSet, bits int, fn func(netip.Prefix)) error {
+	var previous netip.Prefix
+	blocks := 0
+	for _, r := range ipSet.Ranges() {
+		block := netip.PrefixFrom(r.From(), bits).Masked()
+		for {
+			// Ranges are sorted, only the last block of a range may be shared
+			// with the next one.
+			if block != previous {
+				if blocks++; blocks > 1<<MaxPolicyBlockBits {
+					return fmt.Errorf("reserved address policy applies to more than %d blocks of /%d", 1<<MaxPolicyBlockBits, bits)
+				}
+				fn(block)
+				previous = block
+			}
+			last := netipx.RangeOfPrefix(block).To()
+			if !last.Less(r.To()) {
+				break
+			}
+			block = netip.PrefixFrom(last.Next(), bits)
+		}
+	}
+	return nil
+}
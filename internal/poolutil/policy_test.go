@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("ReservedAddressPolicy", func() {
+	It("keeps the subnet boundaries reserved by default", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"10.0.0.0/24"},
+			Prefix:                24,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{FirstAddresses: 3},
+		}
+		ipSet, err := PoolSpecToIPSet(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipSet.Contains(mustParse("10.0.0.0"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("10.0.0.3"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("10.0.0.4"))).To(BeTrue())
+		Expect(ipSet.Contains(mustParse("10.0.0.255"))).To(BeFalse())
+		Expect(IPSetCount(ipSet)).To(Equal(251))
+	})
+
+	It("allocates the subnet boundaries when disabled", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"10.0.0.0/24"},
+			Prefix:                24,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetBoundaries: ptr.To(false)},
+		}
+		ipSet, err := PoolSpecToIPSet(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IPSetCount(ipSet)).To(Equal(256))
+	})
+
+	It("prefers the policy over AllocateReservedIPAddresses", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:                   []string{"10.0.0.0/24"},
+			Prefix:                      24,
+			AllocateReservedIPAddresses: true,
+			ReservedAddressPolicy:       &v1alpha2.ReservedAddressPolicy{SubnetBoundaries: ptr.To(true)},
+		}
+		Expect(ReservesSubnetBoundaries(spec)).To(BeTrue())
+		Expect(ReservesSubnetBoundaries(&v1alpha2.InClusterIPPoolSpec{AllocateReservedIPAddresses: true})).To(BeFalse())
+	})
+
+	It("reserves octet boundaries in subnets larger than /24", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"10.0.0.200-10.0.2.10"},
+			Prefix:                16,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{OctetBoundaries: true},
+		}
+		ipSet, err := PoolSpecToIPSet(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipSet.Contains(mustParse("10.0.0.255"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("10.0.1.0"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("10.0.1.1"))).To(BeTrue())
+		Expect(ipSet.Contains(mustParse("10.0.1.255"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("10.0.2.0"))).To(BeFalse())
+		Expect(IPSetCount(ipSet)).To(Equal(323 - 4))
+	})
+
+	It("does not reserve octet boundaries in /24 subnets or smaller", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"10.0.0.0/24"},
+			Prefix:                24,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{OctetBoundaries: true, SubnetBoundaries: ptr.To(false)},
+		}
+		ipSet, err := PoolSpecToIPSet(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IPSetCount(ipSet)).To(Equal(256))
+	})
+
+	It("reserves the subnet anycast addresses of each /64", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"fd01::ffff:ffff:ffff:ff00-fd01:0:0:1::ff"},
+			Prefix:                48,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true},
+		}
+		ipSet, err := PoolSpecToIPSet(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipSet.Contains(mustParse("fd01::ffff:ffff:ffff:ff7f"))).To(BeTrue())
+		Expect(ipSet.Contains(mustParse("fd01::ffff:ffff:ffff:ff80"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("fd01::ffff:ffff:ffff:ffff"))).To(BeFalse())
+		Expect(ipSet.Contains(mustParse("fd01:0:0:1::"))).To(BeTrue())
+		Expect(IPSetCount(ipSet)).To(Equal(512 - 128))
+	})
+
+	It("refuses to apply the policy to too many blocks", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses:             []string{"::/1"},
+			Prefix:                1,
+			ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true},
+		}
+		_, err := PoolSpecToIPSet(spec)
+		Expect(err).To(MatchError(ContainSubstring("more than 65536 blocks of /64")))
+	})
+})
@@ -19,15 +19,22 @@ package poolutil
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"math"
 	"math/big"
+	"net"
 	"net/netip"
+	"slices"
+	"strconv"
 	"strings"
 
 	"go4.org/netipx"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -72,6 +79,21 @@ func ListAddressesInUse(ctx context.Context, c client.Reader, namespace string,
 	return addr, err
 }
 
+// ListClaims fetches all IPAddressClaims referencing the specified pool.
+// Note: requires `index.IPAddressClaimByCombinedPoolRef` to be set up.
+func ListClaims(ctx context.Context, c client.Reader, namespace string, poolRef corev1.TypedLocalObjectReference) ([]ipamv1.IPAddressClaim, error) {
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := c.List(ctx, claims,
+		client.MatchingFields{
+			index.IPAddressClaimPoolRefCombinedField: index.IPPoolRefValue(poolRef),
+		},
+		client.InNamespace(namespace),
+	); err != nil {
+		return nil, err
+	}
+	return claims.Items, nil
+}
+
 // AddressByNamespacedName finds a specific ip address by namespace and name in a slice of addresses.
 func AddressByNamespacedName(addresses []ipamv1.IPAddress, namespace, name string) *ipamv1.IPAddress {
 	for _, a := range addresses {
@@ -99,9 +121,18 @@ func FindFreeAddress(poolIPSet *netipx.IPSet, inUseIPSet *netipx.IPSet) (netip.A
 	return netip.Addr{}, errors.New("no address available")
 }
 
-// PoolSpecToIPSet converts a pool spec to an IPSet. Reserved addresses will be
-// omitted from the set depending on whether the
-// `spec.AllocateReservedIPAddresses` flag is set.
+// IsPointToPoint returns whether a subnet is a point-to-point link (/31 for
+// IPv4 as per RFC 3021, /127 for IPv6 as per RFC 6164) or a single host
+// (/32, /128). Such subnets have no network, broadcast or anycast address, so
+// all of their addresses can be allocated.
+func IsPointToPoint(subnet netip.Prefix) bool {
+	return subnet.Bits() >= subnet.Addr().BitLen()-1
+}
+
+// PoolSpecToIPSet converts a pool spec to an IPSet. Addresses reserved by the
+// reserved address policy of the pool, or by default the network and
+// broadcast address unless `spec.AllocateReservedIPAddresses` is set, will be
+// omitted from the set.
 func PoolSpecToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec) (*netipx.IPSet, error) {
 	addressesIPSet, err := AddressesToIPSet(poolSpec.Addresses)
 	if err != nil {
@@ -120,14 +151,12 @@ func PoolSpecToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec) (*netipx.IPSet, err
 		builder.RemoveSet(excludedAddressesIPSet)
 	}
 
-	if !poolSpec.AllocateReservedIPAddresses {
-		subnet := netip.PrefixFrom(addressesIPSet.Ranges()[0].From(), poolSpec.Prefix) // safe because of webhook validation
-		subnetRange := netipx.RangeOfPrefix(subnet)
-		builder.Remove(subnetRange.From()) // network addr in IPv4, anycast addr in IPv6
-		if subnet.Addr().Is4() {
-			builder.Remove(subnetRange.To()) // broadcast addr
-		}
+	subnet := netip.PrefixFrom(addressesIPSet.Ranges()[0].From(), poolSpec.Prefix) // safe because of webhook validation
+	reservedIPSet, err := ReservedByPolicyIPSet(poolSpec, subnet, addressesIPSet)
+	if err != nil {
+		return nil, err
 	}
+	builder.RemoveSet(reservedIPSet)
 
 	if poolSpec.Gateway != "" {
 		gateway, err := netip.ParseAddr(poolSpec.Gateway)
@@ -140,46 +169,158 @@ func PoolSpecToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec) (*netipx.IPSet, err
 	return builder.IPSet()
 }
 
-// AddressesToIPSet converts an array of addresses to an AddressesToIPSet
-// addresses may be specified as individual IPs, CIDR ranges, or hyphenated IP
-// ranges.
-func AddressesToIPSet(addresses []string) (*netipx.IPSet, error) {
+// GatewayIPSet returns an IPSet containing the gateway of a pool, or an empty
+// IPSet if the pool has no gateway.
+func GatewayIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec) (*netipx.IPSet, error) {
 	builder := &netipx.IPSetBuilder{}
-	for _, addressStr := range addresses {
-		ipSet, err := AddressToIPSet(addressStr)
+	if poolSpec.Gateway != "" {
+		gateway, err := netip.ParseAddr(poolSpec.Gateway)
 		if err != nil {
 			return nil, err
 		}
-		builder.AddSet(ipSet)
+		builder.Add(gateway)
 	}
 	return builder.IPSet()
 }
 
-// AddressToIPSet converts an addresses to an AddressesToIPSet addresses may be
-// specified as individual IPs, CIDR ranges, or hyphenated IP ranges.
-func AddressToIPSet(addressStr string) (*netipx.IPSet, error) {
-	builder := &netipx.IPSetBuilder{}
+// PrefixFromAddresses returns the prefix length of addresses that consist of
+// a single CIDR, with or without offsets, and whether such a prefix exists.
+func PrefixFromAddresses(addresses []string) (int, bool) {
+	if len(addresses) != 1 {
+		return 0, false
+	}
+	prefix, err := ParseAddressPrefix(addresses[0])
+	if err != nil {
+		return 0, false
+	}
+	return prefix.Bits(), true
+}
 
-	if strings.Contains(addressStr, "-") {
-		addrRange, err := netipx.ParseIPRange(addressStr)
-		if err != nil {
-			return nil, err
+// GatewayFromPolicy returns the gateway selected by the GatewayPolicy of a
+// pool, and whether the policy selects one. Subnets of a single host have no
+// usable address for a gateway.
+func GatewayFromPolicy(poolSpec *v1alpha2.InClusterIPPoolSpec) (netip.Addr, bool) {
+	addressesIPSet, err := AddressesToIPSet(poolSpec.Addresses)
+	if err != nil || len(addressesIPSet.Ranges()) == 0 {
+		return netip.Addr{}, false
+	}
+	subnet := netip.PrefixFrom(addressesIPSet.Ranges()[0].From(), poolSpec.Prefix).Masked()
+	if !subnet.IsValid() || subnet.Bits() == subnet.Addr().BitLen() {
+		return netip.Addr{}, false
+	}
+
+	subnetRange := netipx.RangeOfPrefix(subnet)
+	first, last := subnetRange.From(), subnetRange.To()
+	if !IsPointToPoint(subnet) {
+		first = first.Next() // skip the network addr in IPv4, anycast addr in IPv6
+		if subnet.Addr().Is4() {
+			last = last.Prev() // skip the broadcast addr
 		}
-		builder.AddRange(addrRange)
-	} else if strings.Contains(addressStr, "/") {
-		prefix, err := netip.ParsePrefix(addressStr)
+	}
+
+	switch poolSpec.GatewayPolicy {
+	case v1alpha2.GatewayPolicyFirstUsable:
+		return first, true
+	case v1alpha2.GatewayPolicyLastUsable:
+		return last, true
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+// ReservedAddressesToIPSet returns an IPSet of the reserved addresses of a
+// pool that are part of the poolIPSet.
+func ReservedAddressesToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec, poolIPSet *netipx.IPSet) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, reserved := range poolSpec.ReservedAddresses {
+		ipSet, err := AddressesToIPSet(reserved.Addresses)
 		if err != nil {
 			return nil, err
 		}
-		builder.AddPrefix(prefix)
-	} else {
-		addr, err := netip.ParseAddr(addressStr)
+		builder.AddSet(ipSet)
+	}
+	builder.Intersect(poolIPSet)
+	return builder.IPSet()
+}
+
+// UsableAddressCount returns the number of addresses of a pool that can be
+// allocated to claims, i.e. the addresses of the pool without its reserved
+// addresses.
+func UsableAddressCount(poolSpec *v1alpha2.InClusterIPPoolSpec) (int, error) {
+	poolIPSet, err := PoolSpecToIPSet(poolSpec)
+	if err != nil {
+		return 0, err
+	}
+	reservedIPSet, err := ReservedAddressesToIPSet(poolSpec, poolIPSet)
+	if err != nil {
+		return 0, err
+	}
+	return IPSetCount(poolIPSet) - IPSetCount(reservedIPSet), nil
+}
+
+// RetiringIPSet returns an IPSet of the given allocated addresses that are
+// part of the addresses of a pool but excluded from it. They are retired: they
+// remain valid for their current holder, but are not allocated again.
+func RetiringIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec, addresses []ipamv1.IPAddress) (*netipx.IPSet, error) {
+	if len(poolSpec.ExcludedAddresses) == 0 {
+		return &netipx.IPSet{}, nil
+	}
+	addressesIPSet, err := AddressesToIPSet(poolSpec.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	excludedIPSet, err := AddressesToIPSet(poolSpec.ExcludedAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &netipx.IPSetBuilder{}
+	for _, address := range addresses {
+		if ip, err := netip.ParseAddr(address.Spec.Address); err == nil {
+			builder.Add(ip)
+		}
+	}
+	builder.Intersect(addressesIPSet)
+	builder.Intersect(excludedIPSet)
+	return builder.IPSet()
+}
+
+// AddressesInIPSetCount returns the number of addresses that are part of the
+// ipSet.
+func AddressesInIPSetCount(addresses []ipamv1.IPAddress, ipSet *netipx.IPSet) int {
+	count := 0
+	for _, address := range addresses {
+		if ip, err := netip.ParseAddr(address.Spec.Address); err == nil && ipSet.Contains(ip) {
+			count++
+		}
+	}
+	return count
+}
+
+// AddressesToIPSet converts an array of addresses to an IPSet. See
+// ParseAddressRange for the supported syntax.
+func AddressesToIPSet(addresses []string) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, addressStr := range addresses {
+		ipSet, err := AddressToIPSet(addressStr)
 		if err != nil {
 			return nil, err
 		}
-		builder.Add(addr)
+		builder.AddSet(ipSet)
 	}
+	return builder.IPSet()
+}
 
+// AddressToIPSet converts an address to an IPSet. See ParseAddressRange for
+// the supported syntax.
+func AddressToIPSet(addressStr string) (*netipx.IPSet, error) {
+	addrRange, err := ParseAddressRange(addressStr)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &netipx.IPSetBuilder{}
+	builder.AddRange(addrRange)
 	return builder.IPSet()
 }
 
@@ -214,8 +355,114 @@ func IPSetCount(ipSet *netipx.IPSet) int {
 	return math.MaxInt
 }
 
-// AddressStrParses checks to see that the addresss string is one of
-// a valid single IP address, a hyphonated IP range, or a Prefix.
+// networkMetadataAnnotations contains all annotations managed by
+// SetNetworkMetadataAnnotations.
+var networkMetadataAnnotations = []string{
+	v1alpha2.DNSServersAnnotation,
+	v1alpha2.SearchDomainsAnnotation,
+	v1alpha2.MTUAnnotation,
+	v1alpha2.VLANIDAnnotation,
+	v1alpha2.RoutesAnnotation,
+	v1alpha2.NetmaskAnnotation,
+}
+
+// NetworkMetadataAnnotations returns the annotations describing the network
+// metadata of a pool. It returns nil if the pool has no network metadata.
+func NetworkMetadataAnnotations(poolSpec *v1alpha2.InClusterIPPoolSpec) (map[string]string, error) {
+	metadata := poolSpec.NetworkMetadata
+	if metadata == nil {
+		return nil, nil
+	}
+
+	annotations := map[string]string{}
+	if len(metadata.DNSServers) > 0 {
+		annotations[v1alpha2.DNSServersAnnotation] = strings.Join(metadata.DNSServers, ",")
+	}
+	if len(metadata.SearchDomains) > 0 {
+		annotations[v1alpha2.SearchDomainsAnnotation] = strings.Join(metadata.SearchDomains, ",")
+	}
+	if metadata.MTU != 0 {
+		annotations[v1alpha2.MTUAnnotation] = strconv.Itoa(metadata.MTU)
+	}
+	if metadata.VLANID != 0 {
+		annotations[v1alpha2.VLANIDAnnotation] = strconv.Itoa(metadata.VLANID)
+	}
+	if len(metadata.Routes) > 0 {
+		routes, err := json.Marshal(metadata.Routes)
+		if err != nil {
+			return nil, err
+		}
+		annotations[v1alpha2.RoutesAnnotation] = string(routes)
+	}
+
+	addressesIPSet, err := AddressesToIPSet(poolSpec.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	if ranges := addressesIPSet.Ranges(); len(ranges) > 0 && ranges[0].From().Is4() {
+		annotations[v1alpha2.NetmaskAnnotation] = net.IP(net.CIDRMask(poolSpec.Prefix, 32)).String()
+	}
+
+	return annotations, nil
+}
+
+// SetNetworkMetadataAnnotations sets the network metadata annotations of a
+// pool on an IPAddress and removes annotations that no longer apply.
+func SetNetworkMetadataAnnotations(address *ipamv1.IPAddress, poolSpec *v1alpha2.InClusterIPPoolSpec) error {
+	annotations, err := NetworkMetadataAnnotations(poolSpec)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range networkMetadataAnnotations {
+		if _, ok := annotations[key]; !ok {
+			delete(address.Annotations, key)
+		}
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+	if address.Annotations == nil {
+		address.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		address.Annotations[key] = value
+	}
+	return nil
+}
+
+// NamespaceAllowed reports whether IPAddressClaims from the given namespace
+// may allocate addresses from a pool with the given spec.
+func NamespaceAllowed(ctx context.Context, c client.Reader, poolSpec *v1alpha2.InClusterIPPoolSpec, namespace string) (bool, error) {
+	allowed := poolSpec.AllowedNamespaces
+	if allowed == nil {
+		return true, nil
+	}
+
+	if slices.Contains(allowed.Names, namespace) {
+		return true, nil
+	}
+
+	if allowed.Selector == nil {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(allowed.Selector)
+	if err != nil {
+		return false, err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// AddressStrParses checks to see that the addresss string is a valid address
+// as understood by ParseAddressRange.
 func AddressStrParses(addressStr string) bool {
 	_, err := AddressToIPSet(addressStr)
 	return err == nil
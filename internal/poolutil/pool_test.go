@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go4.org/netipx"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 )
@@ -156,6 +157,39 @@ var _ = Describe("PoolSpecToIPSet", func() {
 			Expect(ipSet.Contains(mustParse("192.168.0.11"))).To(BeFalse())
 			Expect(IPSetCount(ipSet)).To(Equal(6))
 		})
+		It("allocates both addresses of a /31 subnet", func() {
+			spec := &v1alpha2.InClusterIPPoolSpec{
+				Prefix:    31,
+				Addresses: []string{"10.0.0.0/31"},
+			}
+			ipSet, err := PoolSpecToIPSet(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipSet.Contains(mustParse("10.0.0.0"))).To(BeTrue())
+			Expect(ipSet.Contains(mustParse("10.0.0.1"))).To(BeTrue())
+			Expect(IPSetCount(ipSet)).To(Equal(2))
+		})
+		It("allocates the peer address of a /31 subnet with a gateway", func() {
+			spec := &v1alpha2.InClusterIPPoolSpec{
+				Prefix:    31,
+				Gateway:   "10.0.0.0",
+				Addresses: []string{"10.0.0.0/31"},
+			}
+			ipSet, err := PoolSpecToIPSet(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipSet.Contains(mustParse("10.0.0.1"))).To(BeTrue())
+			Expect(IPSetCount(ipSet)).To(Equal(1))
+		})
+		It("allocates the address of a /32 subnet", func() {
+			spec := &v1alpha2.InClusterIPPoolSpec{
+				Prefix:    32,
+				Gateway:   "169.254.0.1",
+				Addresses: []string{"10.0.0.5"},
+			}
+			ipSet, err := PoolSpecToIPSet(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipSet.Contains(mustParse("10.0.0.5"))).To(BeTrue())
+			Expect(IPSetCount(ipSet)).To(Equal(1))
+		})
 		It("excludes ip subnet from the address pool", func() {
 			spec := &v1alpha2.InClusterIPPoolSpec{
 				Gateway: "192.168.0.1",
@@ -261,6 +295,17 @@ var _ = Describe("PoolSpecToIPSet", func() {
 			Expect(ipSet.Contains(mustParse("fd01::1"))).To(BeFalse())
 			Expect(IPSetCount(ipSet)).To(Equal(4))
 		})
+		It("allocates both addresses of a /127 subnet", func() {
+			spec := &v1alpha2.InClusterIPPoolSpec{
+				Prefix:    127,
+				Addresses: []string{"fd01::/127"},
+			}
+			ipSet, err := PoolSpecToIPSet(spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipSet.Contains(mustParse("fd01::"))).To(BeTrue())
+			Expect(ipSet.Contains(mustParse("fd01::1"))).To(BeTrue())
+			Expect(IPSetCount(ipSet)).To(Equal(2))
+		})
 		Context("when the AllocateReservedIPAddresses flag is true", func() {
 			var ipSet *netipx.IPSet
 			BeforeEach(func() {
@@ -296,6 +341,64 @@ var _ = Describe("PoolSpecToIPSet", func() {
 	})
 })
 
+var _ = Describe("PrefixFromAddresses", func() {
+	It("returns the prefix of a single CIDR", func() {
+		prefix, ok := PrefixFromAddresses([]string{"10.0.0.0/24"})
+		Expect(ok).To(BeTrue())
+		Expect(prefix).To(Equal(24))
+
+		prefix, ok = PrefixFromAddresses([]string{"fd01::/64"})
+		Expect(ok).To(BeTrue())
+		Expect(prefix).To(Equal(64))
+	})
+
+	It("returns false for anything but a single CIDR", func() {
+		_, ok := PrefixFromAddresses([]string{"10.0.0.0/24", "10.0.1.0/24"})
+		Expect(ok).To(BeFalse())
+		_, ok = PrefixFromAddresses([]string{"10.0.0.1-10.0.0.20"})
+		Expect(ok).To(BeFalse())
+		_, ok = PrefixFromAddresses(nil)
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("GatewayFromPolicy", func() {
+	DescribeTable("selects the gateway of the subnet",
+		func(addresses []string, prefix int, policy v1alpha2.GatewayPolicy, expected string) {
+			gateway, ok := GatewayFromPolicy(&v1alpha2.InClusterIPPoolSpec{
+				Addresses:     addresses,
+				Prefix:        prefix,
+				GatewayPolicy: policy,
+			})
+			Expect(ok).To(BeTrue())
+			Expect(gateway.String()).To(Equal(expected))
+		},
+		Entry("first usable IPv4 address", []string{"10.0.0.10-10.0.0.20"}, 24, v1alpha2.GatewayPolicyFirstUsable, "10.0.0.1"),
+		Entry("last usable IPv4 address", []string{"10.0.0.10-10.0.0.20"}, 24, v1alpha2.GatewayPolicyLastUsable, "10.0.0.254"),
+		Entry("first usable IPv6 address", []string{"fd01::/64"}, 64, v1alpha2.GatewayPolicyFirstUsable, "fd01::1"),
+		Entry("last usable IPv6 address", []string{"fd01::/64"}, 64, v1alpha2.GatewayPolicyLastUsable, "fd01::ffff:ffff:ffff:ffff"),
+		Entry("first address of a point-to-point subnet", []string{"10.0.0.0/31"}, 31, v1alpha2.GatewayPolicyFirstUsable, "10.0.0.0"),
+		Entry("last address of a point-to-point subnet", []string{"10.0.0.0/31"}, 31, v1alpha2.GatewayPolicyLastUsable, "10.0.0.1"),
+	)
+
+	It("selects no gateway for single host subnets", func() {
+		_, ok := GatewayFromPolicy(&v1alpha2.InClusterIPPoolSpec{
+			Addresses:     []string{"10.0.0.5"},
+			Prefix:        32,
+			GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+		})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("selects no gateway without a policy", func() {
+		_, ok := GatewayFromPolicy(&v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.0/24"},
+			Prefix:    24,
+		})
+		Expect(ok).To(BeFalse())
+	})
+})
+
 var _ = Describe("AddressesToIPSet", func() {
 	It("converts the slice to an IPSet", func() {
 		addressSlice := []string{
@@ -594,6 +697,77 @@ var _ = DescribeTable("IPSetCount", func(expectedCount int, addresses ...string)
 	Entry("ipv6 CIDR", 4, "fe80::1/126"),
 )
 
+var _ = Describe("SetNetworkMetadataAnnotations", func() {
+	var address *ipamv1.IPAddress
+	BeforeEach(func() {
+		address = &ipamv1.IPAddress{}
+	})
+
+	It("does not add annotations when the pool has no network metadata", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    24,
+		}
+		Expect(SetNetworkMetadataAnnotations(address, spec)).To(Succeed())
+		Expect(address.Annotations).To(BeEmpty())
+	})
+
+	It("adds annotations for the network metadata", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    22,
+			NetworkMetadata: &v1alpha2.NetworkMetadata{
+				DNSServers:    []string{"10.0.0.2", "10.0.0.3"},
+				SearchDomains: []string{"example.com", "example.org"},
+				MTU:           9000,
+				VLANID:        100,
+				Routes: []v1alpha2.Route{
+					{To: "10.1.0.0/16", Via: "10.0.0.254", Metric: 100},
+				},
+			},
+		}
+		Expect(SetNetworkMetadataAnnotations(address, spec)).To(Succeed())
+		Expect(address.Annotations).To(Equal(map[string]string{
+			v1alpha2.DNSServersAnnotation:    "10.0.0.2,10.0.0.3",
+			v1alpha2.SearchDomainsAnnotation: "example.com,example.org",
+			v1alpha2.MTUAnnotation:           "9000",
+			v1alpha2.VLANIDAnnotation:        "100",
+			v1alpha2.RoutesAnnotation:        `[{"to":"10.1.0.0/16","via":"10.0.0.254","metric":100}]`,
+			v1alpha2.NetmaskAnnotation:       "255.255.252.0",
+		}))
+	})
+
+	It("does not add a netmask for IPv6 pools", func() {
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"fd00::10-fd00::20"},
+			Prefix:    64,
+			NetworkMetadata: &v1alpha2.NetworkMetadata{
+				MTU: 1500,
+			},
+		}
+		Expect(SetNetworkMetadataAnnotations(address, spec)).To(Succeed())
+		Expect(address.Annotations).To(Equal(map[string]string{
+			v1alpha2.MTUAnnotation: "1500",
+		}))
+	})
+
+	It("removes annotations that no longer apply and keeps unrelated ones", func() {
+		address.Annotations = map[string]string{
+			v1alpha2.DNSServersAnnotation: "10.0.0.2",
+			v1alpha2.NetmaskAnnotation:    "255.255.255.0",
+			"example.com/unrelated":       "value",
+		}
+		spec := &v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    24,
+		}
+		Expect(SetNetworkMetadataAnnotations(address, spec)).To(Succeed())
+		Expect(address.Annotations).To(Equal(map[string]string{
+			"example.com/unrelated": "value",
+		}))
+	})
+})
+
 func mustParse(ipString string) netip.Addr {
 	ip, err := netip.ParseAddr(ipString)
 	ExpectWithOffset(1, err).NotTo(HaveOccurred())
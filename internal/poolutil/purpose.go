@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"sort"
+
+	"go4.org/netipx"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+// ClaimPurpose returns the purpose requested by a claim with the
+// ipam.cluster.x-k8s.io/purpose label, or annotation if the label is not set.
+func ClaimPurpose(claim client.Object) string {
+	if purpose := claim.GetLabels()[v1alpha2.PurposeLabel]; purpose != "" {
+		return purpose
+	}
+	return claim.GetAnnotations()[v1alpha2.PurposeLabel]
+}
+
+// PurposeRangesToIPSet returns an IPSet of the addresses of all purpose ranges
+// of a pool with the given purpose, or of all purpose ranges if the purpose is
+// empty.
+func PurposeRangesToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec, purpose string) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, purposeRange := range poolSpec.PurposeRanges {
+		if purpose != "" && purposeRange.Purpose != purpose {
+			continue
+		}
+		ipSet, err := AddressesToIPSet(purposeRange.Addresses)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddSet(ipSet)
+	}
+	return builder.IPSet()
+}
+
+// PurposeUsage computes the usage of the purpose ranges of each purpose of a
+// pool by the given addresses. Only addresses of the poolIPSet are counted.
+func PurposeUsage(poolSpec *v1alpha2.InClusterIPPoolSpec, poolIPSet *netipx.IPSet, addresses []ipamv1.IPAddress) ([]v1alpha2.PurposeUsage, error) {
+	var purposes []string
+	for _, purposeRange := range poolSpec.PurposeRanges {
+		purposes = append(purposes, purposeRange.Purpose)
+	}
+	sort.Strings(purposes)
+
+	var usage []v1alpha2.PurposeUsage
+	for i, purpose := range purposes {
+		if i > 0 && purposes[i-1] == purpose {
+			continue
+		}
+
+		rangesIPSet, err := PurposeRangesToIPSet(poolSpec, purpose)
+		if err != nil {
+			return nil, err
+		}
+		builder := &netipx.IPSetBuilder{}
+		builder.AddSet(rangesIPSet)
+		builder.Intersect(poolIPSet)
+		ipSet, err := builder.IPSet()
+		if err != nil {
+			return nil, err
+		}
+
+		used := AddressesInIPSetCount(addresses, ipSet)
+		total := IPSetCount(ipSet)
+		usage = append(usage, v1alpha2.PurposeUsage{
+			Purpose: purpose,
+			Total:   total,
+			Used:    used,
+			Free:    total - used,
+		})
+	}
+	return usage, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("Purposes", func() {
+	spec := &v1alpha2.InClusterIPPoolSpec{
+		Addresses: []string{"10.0.0.0/24"},
+		Prefix:    24,
+		Gateway:   "10.0.0.1",
+		PurposeRanges: []v1alpha2.PurposeRange{
+			{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.1-10.0.0.9"}},
+			{Name: "control-plane", Purpose: "control-plane", Addresses: []string{"10.0.0.10-10.0.0.19"}},
+			{Name: "more-vips", Purpose: "vip", Addresses: []string{"10.0.0.250-10.0.0.255"}},
+		},
+	}
+
+	Describe("ClaimPurpose", func() {
+		It("prefers the label over the annotation", func() {
+			claim := &ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{v1alpha2.PurposeLabel: "vip"},
+				Annotations: map[string]string{v1alpha2.PurposeLabel: "control-plane"},
+			}}
+			Expect(ClaimPurpose(claim)).To(Equal("vip"))
+
+			claim.Labels = nil
+			Expect(ClaimPurpose(claim)).To(Equal("control-plane"))
+		})
+	})
+
+	Describe("PurposeRangesToIPSet", func() {
+		It("combines the ranges of a purpose", func() {
+			ipSet, err := PurposeRangesToIPSet(spec, "vip")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IPSetCount(ipSet)).To(Equal(15))
+			Expect(ipSet.Contains(mustParse("10.0.0.10"))).To(BeFalse())
+		})
+
+		It("combines all ranges without a purpose", func() {
+			ipSet, err := PurposeRangesToIPSet(spec, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IPSetCount(ipSet)).To(Equal(25))
+		})
+	})
+
+	Describe("PurposeUsage", func() {
+		It("reports the usage of each purpose within the pool", func() {
+			poolIPSet, err := PoolSpecToIPSet(spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			addresses := []ipamv1.IPAddress{
+				{Spec: ipamv1.IPAddressSpec{Address: "10.0.0.2"}},
+				{Spec: ipamv1.IPAddressSpec{Address: "10.0.0.10"}},
+				{Spec: ipamv1.IPAddressSpec{Address: "10.0.0.100"}},
+			}
+			// The gateway and the broadcast address are not part of the pool.
+			Expect(PurposeUsage(spec, poolIPSet, addresses)).To(Equal([]v1alpha2.PurposeUsage{
+				{Purpose: "control-plane", Total: 10, Used: 1, Free: 9},
+				{Purpose: "vip", Total: 13, Used: 1, Free: 12},
+			}))
+		})
+	})
+})
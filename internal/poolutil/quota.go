@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/index"
+)
+
+// ClaimClusterNames returns the cluster-name label of all IPAddressClaims
+// referencing the specified pool, keyed by the namespaced name of the claim.
+// Note: requires `index.IPAddressClaimByCombinedPoolRef` to be set up.
+func ClaimClusterNames(ctx context.Context, c client.Reader, namespace string, poolRef corev1.TypedLocalObjectReference) (map[types.NamespacedName]string, error) {
+	claims := &ipamv1.IPAddressClaimList{}
+	if err := c.List(ctx, claims,
+		client.MatchingFields{
+			index.IPAddressClaimPoolRefCombinedField: index.IPPoolRefValue(poolRef),
+		},
+		client.InNamespace(namespace),
+	); err != nil {
+		return nil, err
+	}
+
+	clusterNames := map[types.NamespacedName]string{}
+	for _, claim := range claims.Items {
+		if clusterName, ok := claim.Labels[clusterv1.ClusterNameLabel]; ok {
+			clusterNames[types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name}] = clusterName
+		}
+	}
+	return clusterNames, nil
+}
+
+// QuotaValue returns the value a claim in the given namespace and with the
+// given cluster name is counted by for a quota key. It returns false if the
+// quota key does not apply to the claim.
+func QuotaValue(key v1alpha2.QuotaKey, namespace, clusterName string) (string, bool) {
+	switch key {
+	case v1alpha2.QuotaKeyNamespace:
+		return namespace, true
+	case v1alpha2.QuotaKeyCluster:
+		if clusterName == "" {
+			return "", false
+		}
+		return namespace + "/" + clusterName, true
+	default:
+		return "", false
+	}
+}
+
+func quotaAppliesTo(quota v1alpha2.Quota, value string) bool {
+	return len(quota.Values) == 0 || slices.Contains(quota.Values, value)
+}
+
+// countQuota counts the addresses per value of a quota.
+func countQuota(quota v1alpha2.Quota, addresses []ipamv1.IPAddress, clusterNames map[types.NamespacedName]string) map[string]int {
+	used := map[string]int{}
+	for _, value := range quota.Values {
+		used[value] = 0
+	}
+
+	for _, address := range addresses {
+		claimKey := types.NamespacedName{Namespace: address.Namespace, Name: address.Spec.ClaimRef.Name}
+		value, ok := QuotaValue(quota.Key, address.Namespace, clusterNames[claimKey])
+		if !ok || !quotaAppliesTo(quota, value) {
+			continue
+		}
+		used[value]++
+	}
+	return used
+}
+
+// QuotaUsage computes the usage of each quota by the given addresses.
+// clusterNames maps the namespaced name of the addresses' claims to the name
+// of their Cluster.
+func QuotaUsage(quotas []v1alpha2.Quota, addresses []ipamv1.IPAddress, clusterNames map[types.NamespacedName]string) []v1alpha2.QuotaUsage {
+	var usage []v1alpha2.QuotaUsage
+	for _, quota := range quotas {
+		used := countQuota(quota, addresses, clusterNames)
+
+		values := make([]string, 0, len(used))
+		for value := range used {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+
+		for _, value := range values {
+			usage = append(usage, v1alpha2.QuotaUsage{
+				Key:   quota.Key,
+				Value: value,
+				Used:  used[value],
+				Limit: quota.Limit,
+			})
+		}
+	}
+	return usage
+}
+
+// CheckQuotas returns an error if allocating another address for a claim in
+// the given namespace and with the given cluster name would exceed a quota.
+func CheckQuotas(quotas []v1alpha2.Quota, addresses []ipamv1.IPAddress, clusterNames map[types.NamespacedName]string, namespace, clusterName string) error {
+	for _, quota := range quotas {
+		value, ok := QuotaValue(quota.Key, namespace, clusterName)
+		if !ok || !quotaAppliesTo(quota, value) {
+			continue
+		}
+
+		if used := countQuota(quota, addresses, clusterNames)[value]; used >= quota.Limit {
+			return fmt.Errorf("quota of %s %s exceeded: %d of %d addresses in use", quota.Key, value, used, quota.Limit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("Quotas", func() {
+	newAddress := func(namespace, name string) ipamv1.IPAddress {
+		return ipamv1.IPAddress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: ipamv1.IPAddressSpec{
+				ClaimRef: corev1.LocalObjectReference{Name: name},
+			},
+		}
+	}
+
+	addresses := []ipamv1.IPAddress{
+		newAddress("team-a", "a-1"),
+		newAddress("team-a", "a-2"),
+		newAddress("team-a", "a-3"),
+		newAddress("team-b", "b-1"),
+	}
+	clusterNames := map[types.NamespacedName]string{
+		{Namespace: "team-a", Name: "a-1"}: "cluster-1",
+		{Namespace: "team-a", Name: "a-2"}: "cluster-1",
+		{Namespace: "team-a", Name: "a-3"}: "cluster-2",
+	}
+
+	Describe("QuotaUsage", func() {
+		It("reports the usage of every namespace when the quota has no values", func() {
+			quotas := []v1alpha2.Quota{{Key: v1alpha2.QuotaKeyNamespace, Limit: 5}}
+			Expect(QuotaUsage(quotas, addresses, clusterNames)).To(Equal([]v1alpha2.QuotaUsage{
+				{Key: v1alpha2.QuotaKeyNamespace, Value: "team-a", Used: 3, Limit: 5},
+				{Key: v1alpha2.QuotaKeyNamespace, Value: "team-b", Used: 1, Limit: 5},
+			}))
+		})
+
+		It("reports the usage of the listed clusters only", func() {
+			quotas := []v1alpha2.Quota{{Key: v1alpha2.QuotaKeyCluster, Values: []string{"team-a/cluster-1", "team-b/cluster-3"}, Limit: 2}}
+			Expect(QuotaUsage(quotas, addresses, clusterNames)).To(Equal([]v1alpha2.QuotaUsage{
+				{Key: v1alpha2.QuotaKeyCluster, Value: "team-a/cluster-1", Used: 2, Limit: 2},
+				{Key: v1alpha2.QuotaKeyCluster, Value: "team-b/cluster-3", Used: 0, Limit: 2},
+			}))
+		})
+
+		It("returns nil without quotas", func() {
+			Expect(QuotaUsage(nil, addresses, clusterNames)).To(BeNil())
+		})
+	})
+
+	Describe("CheckQuotas", func() {
+		quotas := []v1alpha2.Quota{
+			{Key: v1alpha2.QuotaKeyNamespace, Values: []string{"team-a"}, Limit: 4},
+			{Key: v1alpha2.QuotaKeyCluster, Limit: 2},
+		}
+
+		It("allows claims that stay within all quotas", func() {
+			Expect(CheckQuotas(quotas, addresses, clusterNames, "team-a", "cluster-2")).To(Succeed())
+			Expect(CheckQuotas(quotas, addresses, clusterNames, "team-b", "")).To(Succeed())
+		})
+
+		It("rejects claims of a cluster that exhausted its quota", func() {
+			Expect(CheckQuotas(quotas, addresses, clusterNames, "team-a", "cluster-1")).To(
+				MatchError("quota of Cluster team-a/cluster-1 exceeded: 2 of 2 addresses in use"))
+		})
+
+		It("rejects claims of a namespace that exhausted its quota", func() {
+			withFourth := append([]ipamv1.IPAddress{newAddress("team-a", "a-4")}, addresses...)
+			Expect(CheckQuotas(quotas, withFourth, clusterNames, "team-a", "")).To(
+				MatchError("quota of Namespace team-a exceeded: 4 of 4 addresses in use"))
+		})
+	})
+})
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimitWindow is the window rate limits of pools apply to.
+const RateLimitWindow = time.Minute
+
+// RateLimit limits the number of allocations per RateLimitWindow for a key.
+type RateLimit struct {
+	Key   string
+	Limit int
+}
+
+// AllocationRateLimiter tracks allocations in a sliding window to enforce
+// rate limits. Its state is only kept in memory, so it starts empty whenever
+// the controller is restarted.
+type AllocationRateLimiter struct {
+	mu          sync.Mutex
+	now         func() time.Time
+	allocations map[string][]time.Time
+}
+
+// NewAllocationRateLimiter returns an empty AllocationRateLimiter.
+func NewAllocationRateLimiter() *AllocationRateLimiter {
+	return &AllocationRateLimiter{
+		now:         time.Now,
+		allocations: map[string][]time.Time{},
+	}
+}
+
+// Reserve records an allocation for all limits if none of them is exceeded.
+// Otherwise it records nothing and returns the first exceeded limit together
+// with the time until it allows the next allocation.
+func (l *AllocationRateLimiter) Reserve(limits ...RateLimit) (*RateLimit, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for i := range limits {
+		allocations := l.prune(limits[i].Key, now)
+		if len(allocations) >= limits[i].Limit {
+			return &limits[i], allocations[len(allocations)-limits[i].Limit].Add(RateLimitWindow).Sub(now)
+		}
+	}
+
+	for _, limit := range limits {
+		l.allocations[limit.Key] = append(l.allocations[limit.Key], now)
+	}
+	return nil, 0
+}
+
+// Release drops the most recent allocation of all limits, e.g. because the
+// address that was reserved could not be created.
+func (l *AllocationRateLimiter) Release(limits ...RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, limit := range limits {
+		allocations := l.allocations[limit.Key]
+		if len(allocations) <= 1 {
+			delete(l.allocations, limit.Key)
+			continue
+		}
+		l.allocations[limit.Key] = allocations[:len(allocations)-1]
+	}
+}
+
+// prune drops the allocations of a key that are outside of the window.
+func (l *AllocationRateLimiter) prune(key string, now time.Time) []time.Time {
+	allocations := l.allocations[key]
+	i := 0
+	for i < len(allocations) && !allocations[i].Add(RateLimitWindow).After(now) {
+		i++
+	}
+	allocations = allocations[i:]
+	if len(allocations) == 0 {
+		delete(l.allocations, key)
+		return nil
+	}
+	l.allocations[key] = allocations
+	return allocations
+}
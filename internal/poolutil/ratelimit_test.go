@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AllocationRateLimiter", func() {
+	var limiter *AllocationRateLimiter
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		limiter = NewAllocationRateLimiter()
+		limiter.now = func() time.Time { return now }
+	})
+
+	It("allows allocations up to the limit within the window", func() {
+		poolLimit := RateLimit{Key: "pool", Limit: 2}
+
+		Expect(limiter.Reserve(poolLimit)).To(BeNil())
+		now = now.Add(10 * time.Second)
+		Expect(limiter.Reserve(poolLimit)).To(BeNil())
+
+		now = now.Add(10 * time.Second)
+		exceeded, wait := limiter.Reserve(poolLimit)
+		Expect(exceeded).To(Equal(&poolLimit))
+		Expect(wait).To(Equal(40 * time.Second))
+
+		now = now.Add(40 * time.Second)
+		Expect(limiter.Reserve(poolLimit)).To(BeNil())
+	})
+
+	It("records nothing if any limit is exceeded", func() {
+		poolLimit := RateLimit{Key: "pool", Limit: 2}
+		clusterLimit := RateLimit{Key: "cluster", Limit: 1}
+
+		Expect(limiter.Reserve(poolLimit, clusterLimit)).To(BeNil())
+		exceeded, _ := limiter.Reserve(poolLimit, clusterLimit)
+		Expect(exceeded).To(Equal(&clusterLimit))
+
+		otherClusterLimit := RateLimit{Key: "other-cluster", Limit: 1}
+		Expect(limiter.Reserve(poolLimit, otherClusterLimit)).To(BeNil())
+		exceeded, _ = limiter.Reserve(poolLimit)
+		Expect(exceeded).To(Equal(&poolLimit))
+	})
+
+	It("allows another allocation once a reservation is released", func() {
+		poolLimit := RateLimit{Key: "pool", Limit: 2}
+		clusterLimit := RateLimit{Key: "cluster", Limit: 1}
+
+		Expect(limiter.Reserve(poolLimit, clusterLimit)).To(BeNil())
+		now = now.Add(10 * time.Second)
+		Expect(limiter.Reserve(poolLimit)).To(BeNil())
+
+		limiter.Release(poolLimit)
+		Expect(limiter.Reserve(poolLimit)).To(BeNil())
+		exceeded, wait := limiter.Reserve(poolLimit)
+		Expect(exceeded).To(Equal(&poolLimit))
+		Expect(wait).To(Equal(50 * time.Second))
+
+		limiter.Release(clusterLimit)
+		Expect(limiter.Reserve(clusterLimit)).To(BeNil())
+	})
+})
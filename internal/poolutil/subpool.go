@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"go4.org/netipx"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+// ListSubPools fetches all InClusterIPPools that were carved out of the
+// GlobalInClusterIPPool with the given name.
+func ListSubPools(ctx context.Context, c client.Reader, parentName string) ([]v1alpha2.InClusterIPPool, error) {
+	pools := &v1alpha2.InClusterIPPoolList{}
+	if err := c.List(ctx, pools, client.MatchingLabels{v1alpha2.SubPoolOfLabel: parentName}); err != nil {
+		return nil, err
+	}
+	return pools.Items, nil
+}
+
+// SubPoolsToIPSet returns an IPSet containing the addresses of all given sub-pools.
+func SubPoolsToIPSet(subPools []v1alpha2.InClusterIPPool) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, subPool := range subPools {
+		ipSet, err := AddressesToIPSet(subPool.Spec.Addresses)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddSet(ipSet)
+	}
+	return builder.IPSet()
+}
+
+// FindFreePrefix returns the first prefix with the given length that is
+// entirely contained in freeIPSet.
+func FindFreePrefix(freeIPSet *netipx.IPSet, bits int) (netip.Prefix, error) {
+	for _, iprange := range freeIPSet.Ranges() {
+		for _, prefix := range iprange.Prefixes() {
+			// The address of a prefix is aligned to its length, and thereby
+			// to every longer prefix length as well.
+			if prefix.Bits() <= bits && bits <= prefix.Addr().BitLen() {
+				return netip.PrefixFrom(prefix.Addr(), bits), nil
+			}
+		}
+	}
+	return netip.Prefix{}, fmt.Errorf("no free block of /%d available", bits)
+}
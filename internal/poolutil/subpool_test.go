@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go4.org/netipx"
+)
+
+var _ = Describe("FindFreePrefix", func() {
+	ipSet := func(addresses ...string) *netipx.IPSet {
+		set, err := AddressesToIPSet(addresses)
+		Expect(err).NotTo(HaveOccurred())
+		return set
+	}
+
+	It("returns the first aligned block", func() {
+		prefix, err := FindFreePrefix(ipSet("10.0.0.2-10.0.0.255"), 28)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefix).To(Equal(netip.MustParsePrefix("10.0.0.16/28")))
+	})
+
+	It("skips ranges that are too small", func() {
+		prefix, err := FindFreePrefix(ipSet("10.0.0.0/29", "10.0.0.12-10.0.0.40"), 28)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefix).To(Equal(netip.MustParsePrefix("10.0.0.16/28")))
+	})
+
+	It("supports IPv6", func() {
+		prefix, err := FindFreePrefix(ipSet("fe80::2-fe80::ffff"), 120)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefix).To(Equal(netip.MustParsePrefix("fe80::100/120")))
+	})
+
+	It("returns an error when no block is free", func() {
+		_, err := FindFreePrefix(ipSet("10.0.0.1-10.0.0.30"), 27)
+		Expect(err).To(MatchError("no free block of /27 available"))
+	})
+})
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	"go4.org/netipx"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+// FailureDomainLabel returns the label of the address subsets of a pool that
+// contains their failure domain.
+func FailureDomainLabel(poolSpec *v1alpha2.InClusterIPPoolSpec) string {
+	if poolSpec.FailureDomainLabel != "" {
+		return poolSpec.FailureDomainLabel
+	}
+	return corev1.LabelTopologyZone
+}
+
+// AddressSubsetsToIPSet returns an IPSet of the addresses of all address
+// subsets of a pool that have the given label.
+func AddressSubsetsToIPSet(poolSpec *v1alpha2.InClusterIPPoolSpec, key, value string) (*netipx.IPSet, error) {
+	builder := &netipx.IPSetBuilder{}
+	for _, subset := range poolSpec.AddressSubsets {
+		if v, ok := subset.Labels[key]; !ok || v != value {
+			continue
+		}
+		ipSet, err := AddressesToIPSet(subset.Addresses)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddSet(ipSet)
+	}
+	return builder.IPSet()
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolutil
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+var _ = Describe("AddressSubsetsToIPSet", func() {
+	spec := &v1alpha2.InClusterIPPoolSpec{
+		Addresses: []string{"10.0.0.0/24"},
+		Prefix:    24,
+		AddressSubsets: []v1alpha2.AddressSubset{
+			{Addresses: []string{"10.0.0.10-10.0.0.19"}, Labels: map[string]string{"zone": "a", "rack": "1"}},
+			{Addresses: []string{"10.0.0.20-10.0.0.29"}, Labels: map[string]string{"zone": "a", "rack": "2"}},
+			{Addresses: []string{"10.0.0.30-10.0.0.39"}, Labels: map[string]string{"zone": "b"}},
+		},
+	}
+
+	It("combines all subsets with the label", func() {
+		ipSet, err := AddressSubsetsToIPSet(spec, "zone", "a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IPSetCount(ipSet)).To(Equal(20))
+		Expect(ipSet.Contains(mustParse("10.0.0.10"))).To(BeTrue())
+		Expect(ipSet.Contains(mustParse("10.0.0.29"))).To(BeTrue())
+		Expect(ipSet.Contains(mustParse("10.0.0.30"))).To(BeFalse())
+	})
+
+	It("returns an empty set if no subset has the label", func() {
+		ipSet, err := AddressSubsetsToIPSet(spec, "zone", "c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IPSetCount(ipSet)).To(Equal(0))
+	})
+
+	It("defaults the failure domain label", func() {
+		Expect(FailureDomainLabel(spec)).To(Equal("topology.kubernetes.io/zone"))
+		Expect(FailureDomainLabel(&v1alpha2.InClusterIPPoolSpec{FailureDomainLabel: "rack"})).To(Equal("rack"))
+	})
+})
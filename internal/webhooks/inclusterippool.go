@@ -20,11 +20,16 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
 
 	"go4.org/netipx"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -42,6 +47,10 @@ const (
 	// to the InClusterIPPool or GlobalInClusterIPPool to skip delete
 	// validation. Necessary for clusterctl move to work as expected.
 	SkipValidateDeleteWebhookAnnotation = "ipam.cluster.x-k8s.io/skip-validate-delete-webhook"
+
+	// minUsableAddresses is the number of usable addresses below which a pool
+	// is reported as likely too small.
+	minUsableAddresses = 4
 )
 
 func (webhook *InClusterIPPool) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -76,18 +85,46 @@ var (
 	_ webhook.CustomValidator = &InClusterIPPool{}
 )
 
-// Default satisfies the defaulting webhook interface.
-func (webhook *InClusterIPPool) Default(_ context.Context, _ runtime.Object) error {
+// Default satisfies the defaulting webhook interface. It derives the prefix
+// from a single CIDR and the gateway from the gateway policy, if they are
+// not set.
+func (webhook *InClusterIPPool) Default(_ context.Context, obj runtime.Object) error {
+	pool, ok := obj.(types.GenericInClusterPool)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a InClusterIPPool or an GlobalInClusterIPPool but got a %T", obj))
+	}
+
+	spec := pool.PoolSpec()
+	if spec.Prefix == 0 {
+		if prefix, ok := poolutil.PrefixFromAddresses(spec.Addresses); ok {
+			spec.Prefix = prefix
+		}
+	}
+	if spec.Gateway == "" && spec.GatewayPolicy != "" {
+		if gateway, ok := poolutil.GatewayFromPolicy(spec); ok {
+			spec.Gateway = gateway.String()
+		}
+	}
 	return nil
 }
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
-func (webhook *InClusterIPPool) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+func (webhook *InClusterIPPool) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	pool, ok := obj.(types.GenericInClusterPool)
 	if !ok {
 		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a InClusterIPPool or an GlobalInClusterIPPool but got a %T", obj))
 	}
-	return nil, webhook.validate(nil, pool)
+	if err := webhook.validate(nil, pool); err != nil {
+		return nil, err
+	}
+	if err := webhook.validateParentRef(ctx, pool); err != nil {
+		return nil, err
+	}
+	warnings, err := webhook.validateOverlap(ctx, pool)
+	if err != nil {
+		return warnings, err
+	}
+	return append(warnings, configurationWarnings(pool.PoolSpec())...), nil
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -106,6 +143,21 @@ func (webhook *InClusterIPPool) ValidateUpdate(ctx context.Context, oldObj, newO
 		return nil, err
 	}
 
+	if err := webhook.validateParentRef(ctx, newPool); err != nil {
+		return nil, err
+	}
+
+	// Pools that overlapped before the check existed are only checked once
+	// their addresses change, so that they can still be updated, e.g. to
+	// remove their finalizer.
+	var warnings admission.Warnings
+	if newPool.GetDeletionTimestamp().IsZero() && addressesChanged(oldPool, newPool) {
+		if warnings, err = webhook.validateOverlap(ctx, newPool); err != nil {
+			return warnings, err
+		}
+	}
+	warnings = append(warnings, configurationWarnings(newPool.PoolSpec())...)
+
 	oldPoolRef := corev1.TypedLocalObjectReference{
 		APIGroup: ptr.To(v1alpha2.GroupVersion.Group),
 		Kind:     oldPool.GetObjectKind().GroupVersionKind().Kind,
@@ -125,6 +177,26 @@ func (webhook *InClusterIPPool) ValidateUpdate(ctx context.Context, oldObj, newO
 		}
 		inUseBuilder.Add(ip)
 	}
+	if _, ok := newPool.(*v1alpha2.GlobalInClusterIPPool); ok {
+		subPools, err := poolutil.ListSubPools(ctx, webhook.Client, oldPool.GetName())
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		subPoolIPSet, err := poolutil.SubPoolsToIPSet(subPools)
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		inUseBuilder.AddSet(subPoolIPSet)
+	}
+	childPools, err := poolutil.ListChildPools(ctx, webhook.Client, oldPool)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	childPoolIPSet, err := poolutil.ChildPoolsToIPSet(childPools)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	inUseBuilder.AddSet(childPoolIPSet)
 	newPoolIPSet, err := poolutil.PoolSpecToIPSet(newPool.PoolSpec())
 	if err != nil {
 		// these addresses are already validated, this shouldn't happen
@@ -132,16 +204,38 @@ func (webhook *InClusterIPPool) ValidateUpdate(ctx context.Context, oldObj, newO
 	}
 
 	inUseBuilder.RemoveSet(newPoolIPSet)
+
+	// The gateway may be allocated by router claims.
+	gatewayIPSet, err := poolutil.GatewayIPSet(newPool.PoolSpec())
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	inUseBuilder.RemoveSet(gatewayIPSet)
+
+	// Allocated addresses that are excluded are retired instead of rejected.
+	retiringIPSet, err := poolutil.RetiringIPSet(newPool.PoolSpec(), inUseAddresses)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	inUseBuilder.RemoveSet(retiringIPSet)
+	if retiring := retiringIPSet.Ranges(); len(retiring) > 0 {
+		warnings = append(warnings, fmt.Sprintf("excluded addresses are allocated and will be retired once released: %v", retiring))
+	}
+
 	outOfRangeIPSet, err := inUseBuilder.IPSet()
 	if err != nil {
 		return nil, apierrors.NewInternalError(err)
 	}
 
 	if outOfRange := outOfRangeIPSet.Ranges(); len(outOfRange) > 0 {
-		return nil, apierrors.NewBadRequest(fmt.Sprintf("pool addresses do not contain allocated addresses: %v", outOfRange))
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("pool addresses do not contain allocated addresses: %v", outOfRange))
 	}
 
-	return nil, nil
+	capacityWarnings, err := webhook.capacityWarnings(ctx, oldPool, newPool, oldPoolRef)
+	if err != nil {
+		return warnings, apierrors.NewInternalError(err)
+	}
+	return append(warnings, capacityWarnings...), nil
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
@@ -155,6 +249,28 @@ func (webhook *InClusterIPPool) ValidateDelete(ctx context.Context, obj runtime.
 		return nil, nil
 	}
 
+	if _, ok := pool.(*v1alpha2.GlobalInClusterIPPool); ok {
+		subPools, err := poolutil.ListSubPools(ctx, webhook.Client, pool.GetName())
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		if len(subPools) > 0 {
+			return nil, apierrors.NewBadRequest("Pool has sub-pools. Cannot delete Pool until all sub-pools have been removed.")
+		}
+	}
+
+	childPools, err := poolutil.ListChildPools(ctx, webhook.Client, pool)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	if len(childPools) > 0 {
+		return nil, apierrors.NewBadRequest("Pool has child pools. Cannot delete Pool until all child pools have been removed.")
+	}
+
+	if pool.PoolSpec().DeletionPolicy == v1alpha2.DeletionPolicyCascade {
+		return nil, nil
+	}
+
 	poolTypeRef := corev1.TypedLocalObjectReference{
 		APIGroup: ptr.To(pool.GetObjectKind().GroupVersionKind().Group),
 		Kind:     pool.GetObjectKind().GroupVersionKind().Kind,
@@ -173,7 +289,7 @@ func (webhook *InClusterIPPool) ValidateDelete(ctx context.Context, obj runtime.
 	return nil, nil
 }
 
-func (webhook *InClusterIPPool) validate(_, newPool types.GenericInClusterPool) (reterr error) {
+func (webhook *InClusterIPPool) validate(oldPool, newPool types.GenericInClusterPool) (reterr error) {
 	var allErrs field.ErrorList
 	defer func() {
 		if len(allErrs) > 0 {
@@ -190,10 +306,10 @@ func (webhook *InClusterIPPool) validate(_, newPool types.GenericInClusterPool)
 	}
 
 	var hasIPv4Addr, hasIPv6Addr bool
-	for _, address := range newPool.PoolSpec().Addresses {
+	for i, address := range newPool.PoolSpec().Addresses {
 		ipSet, err := poolutil.AddressToIPSet(address)
 		if err != nil {
-			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "addresses"), address, "provided address is not a valid IP, range, nor CIDR"))
+			allErrs = append(allErrs, invalidAddress(field.NewPath("spec", "addresses").Index(i), address, err))
 			continue
 		}
 		from := ipSet.Ranges()[0].From()
@@ -213,13 +329,15 @@ func (webhook *InClusterIPPool) validate(_, newPool types.GenericInClusterPool)
 		if gateway.Is6() && hasIPv4Addr || gateway.Is4() && hasIPv6Addr {
 			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "gateway"), newPool.PoolSpec().Gateway, "provided gateway and addresses are of mixed IP families"))
 		}
+	} else if newPool.PoolSpec().GatewayPolicy != "" {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "gatewayPolicy"), newPool.PoolSpec().GatewayPolicy, "no gateway can be derived from the addresses and prefix"))
 	}
 
 	excludedHasIPv4Addr, excludedHasIPv6Addr := false, false
-	for _, address := range newPool.PoolSpec().ExcludedAddresses {
+	for i, address := range newPool.PoolSpec().ExcludedAddresses {
 		ipSet, err := poolutil.AddressToIPSet(address)
 		if err != nil {
-			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "excludedAddresses"), address, "provided address is not a valid IP, range, nor CIDR"))
+			allErrs = append(allErrs, invalidAddress(field.NewPath("spec", "excludedAddresses").Index(i), address, err))
 			continue
 		}
 		from := ipSet.Ranges()[0].From()
@@ -235,6 +353,38 @@ func (webhook *InClusterIPPool) validate(_, newPool types.GenericInClusterPool)
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "excludedAddresses"), newPool.PoolSpec().ExcludedAddresses, "addresses and excluded addresses are of mixed IP families"))
 	}
 
+	if newPool.PoolSpec().NetworkMetadata != nil {
+		allErrs = append(allErrs, validateNetworkMetadata(newPool.PoolSpec().NetworkMetadata)...)
+	}
+
+	if newPool.PoolSpec().AllowedNamespaces != nil {
+		allErrs = append(allErrs, validateAllowedNamespaces(newPool)...)
+	}
+
+	allErrs = append(allErrs, validateQuotas(newPool.PoolSpec().Quotas)...)
+
+	if newPool.PoolSpec().ClusterSubPools != nil {
+		allErrs = append(allErrs, validateClusterSubPools(newPool, hasIPv6Addr)...)
+	}
+
+	allErrs = append(allErrs, validateOwnerCluster(oldPool, newPool)...)
+
+	poolIPSet, err := poolutil.AddressesToIPSet(newPool.PoolSpec().Addresses)
+	if err != nil {
+		// invalid addresses are already reported by the validation of the addresses
+		poolIPSet = &netipx.IPSet{}
+	}
+
+	allErrs = append(allErrs, validateAddressSubsets(newPool.PoolSpec(), poolIPSet)...)
+
+	allErrs = append(allErrs, validatePurposeRanges(newPool.PoolSpec(), poolIPSet)...)
+
+	allErrs = append(allErrs, validateReservedAddresses(newPool.PoolSpec(), poolIPSet)...)
+
+	if hasIPv4Addr != hasIPv6Addr {
+		allErrs = append(allErrs, validateReservedAddressPolicy(newPool.PoolSpec(), hasIPv4Addr)...)
+	}
+
 	if len(allErrs) == 0 {
 		errs := validateAddressesAreWithinPrefix(newPool.PoolSpec())
 		if len(errs) != 0 {
@@ -242,9 +392,580 @@ func (webhook *InClusterIPPool) validate(_, newPool types.GenericInClusterPool)
 		}
 	}
 
+	// The gateway can only be checked against the subnet once the addresses
+	// are known to be within it. Pools accepted before the check existed are
+	// only checked once their gateway or subnet changes, so that they can
+	// still be updated, e.g. to remove their finalizer.
+	if len(allErrs) == 0 && newPool.PoolSpec().Gateway != "" && gatewayOrSubnetChanged(oldPool, newPool) {
+		allErrs = append(allErrs, validateGateway(newPool.PoolSpec())...)
+	}
+
 	return //nolint:nakedret
 }
 
+// validateOverlap rejects pools whose addresses overlap with the addresses of
+// other pools, unless one of the pools allows overlapping. Allowed overlaps
+// are reported as warnings.
+func (webhook *InClusterIPPool) validateOverlap(ctx context.Context, pool types.GenericInClusterPool) (admission.Warnings, error) {
+	poolIPSet, err := poolutil.PoolSpecToIPSet(pool.PoolSpec())
+	if err != nil {
+		// these addresses are already validated, this shouldn't happen
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	overlapping, err := poolutil.OverlappingPools(ctx, webhook.Client, pool, poolIPSet)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	for _, other := range overlapping {
+		// Sub-pools and child pools are carved out of their ancestors on purpose.
+		isAncestor, err := webhook.isAncestorOf(ctx, other, pool)
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		isDescendant, err := webhook.isAncestorOf(ctx, pool, other)
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		if isAncestor || isDescendant {
+			continue
+		}
+
+		msg := fmt.Sprintf("addresses overlap with %s %s", poolutil.PoolKind(other), poolName(other))
+		if pool.PoolSpec().AllowOverlap || other.PoolSpec().AllowOverlap {
+			warnings = append(warnings, msg)
+			continue
+		}
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "addresses"), pool.PoolSpec().Addresses,
+			msg+", set allowOverlap to allow overlapping pools"))
+	}
+
+	if len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind(poolutil.PoolKind(pool)).GroupKind(), pool.GetName(), allErrs)
+	}
+	return warnings, nil
+}
+
+// configurationWarnings reports pool configurations that are valid, but are
+// likely mistakes.
+func configurationWarnings(spec *v1alpha2.InClusterIPPoolSpec) admission.Warnings {
+	var warnings admission.Warnings
+
+	addressesIPSet, err := poolutil.AddressesToIPSet(spec.Addresses)
+	if err != nil {
+		return nil // invalid pools are rejected by the validation
+	}
+	for i, address := range spec.ExcludedAddresses {
+		excludedIPSet, err := poolutil.AddressToIPSet(address)
+		if err == nil && !addressesIPSet.Overlaps(excludedIPSet) {
+			warnings = append(warnings, fmt.Sprintf("spec.excludedAddresses[%d]: %s is not within the addresses of the pool and has no effect", i, address))
+		}
+	}
+
+	if gateway, err := netip.ParseAddr(spec.Gateway); err == nil {
+		for i, address := range spec.Addresses {
+			if addrRange, err := poolutil.ParseAddressRange(address); err == nil && addrRange.From() == gateway && addrRange.To() == gateway {
+				warnings = append(warnings, fmt.Sprintf("spec.addresses[%d]: the gateway %s is never allocated to regular claims", i, address))
+			}
+		}
+	}
+
+	// Point-to-point and single host pools are small by design.
+	subnet := netip.PrefixFrom(addressesIPSet.Ranges()[0].From(), spec.Prefix)
+	if usable, err := poolutil.UsableAddressCount(spec); err == nil && usable < minUsableAddresses && !poolutil.IsPointToPoint(subnet) {
+		warnings = append(warnings, fmt.Sprintf("the pool has only %d usable addresses", usable))
+	}
+
+	return warnings
+}
+
+// capacityWarnings reports updates that shrink the usable addresses of a pool
+// below the number of claims referencing it.
+func (webhook *InClusterIPPool) capacityWarnings(ctx context.Context, oldPool, newPool types.GenericInClusterPool, poolRef corev1.TypedLocalObjectReference) (admission.Warnings, error) {
+	oldUsable, err := poolutil.UsableAddressCount(oldPool.PoolSpec())
+	if err != nil {
+		// the old pool may predate validations, treat it as not shrinking
+		return nil, nil //nolint:nilerr
+	}
+	newUsable, err := poolutil.UsableAddressCount(newPool.PoolSpec())
+	if err != nil {
+		return nil, err
+	}
+	if newUsable >= oldUsable {
+		return nil, nil
+	}
+
+	claims, err := poolutil.ListClaims(ctx, webhook.Client, oldPool.GetNamespace(), poolRef)
+	if err != nil {
+		return nil, err
+	}
+	demand := 0
+	for _, claim := range claims {
+		if claim.DeletionTimestamp.IsZero() {
+			demand++
+		}
+	}
+	if newUsable < demand {
+		return admission.Warnings{fmt.Sprintf("the pool has %d usable addresses, fewer than the %d claims referencing it", newUsable, demand)}, nil
+	}
+	return nil, nil
+}
+
+// validateParentRef ensures that the addresses of a child pool are within the
+// addresses of its parent and don't overlap with the addresses of its
+// siblings.
+func (webhook *InClusterIPPool) validateParentRef(ctx context.Context, pool types.GenericInClusterPool) (reterr error) {
+	ref := pool.PoolSpec().ParentRef
+	if ref == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	defer func() {
+		if len(allErrs) > 0 {
+			reterr = apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind(poolutil.PoolKind(pool)).GroupKind(), pool.GetName(), allErrs)
+		}
+	}()
+
+	parentRefPath := field.NewPath("spec", "parentRef")
+	if _, ok := pool.(*v1alpha2.GlobalInClusterIPPool); ok && ref.Kind != "GlobalInClusterIPPool" {
+		allErrs = append(allErrs, field.Invalid(parentRefPath.Child("kind"), ref.Kind, "the parent of a GlobalInClusterIPPool must be a GlobalInClusterIPPool"))
+		return nil
+	}
+
+	parent, err := poolutil.GetParentPool(ctx, webhook.Client, pool)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, field.NotFound(parentRefPath, ref.Name))
+			return nil
+		}
+		return apierrors.NewInternalError(err)
+	}
+
+	ancestors, err := poolutil.Ancestors(ctx, webhook.Client, pool)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if poolutil.ContainsPool(ancestors, pool) {
+		allErrs = append(allErrs, field.Invalid(parentRefPath, ref.Name, "parentRef must not form a cycle"))
+		return nil
+	}
+
+	poolIPSet, err := poolutil.PoolSpecToIPSet(pool.PoolSpec())
+	if err != nil {
+		// these addresses are already validated, this shouldn't happen
+		return apierrors.NewInternalError(err)
+	}
+	parentIPSet, err := poolutil.PoolSpecToIPSet(parent.PoolSpec())
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	builder := &netipx.IPSetBuilder{}
+	builder.AddSet(poolIPSet)
+	builder.RemoveSet(parentIPSet)
+	outsideIPSet, err := builder.IPSet()
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if outside := outsideIPSet.Ranges(); len(outside) > 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "addresses"), pool.PoolSpec().Addresses,
+			fmt.Sprintf("addresses must be within the addresses of the parent pool, %v are not", outside)))
+	}
+
+	siblings, err := poolutil.ListChildPools(ctx, webhook.Client, parent)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	for _, sibling := range siblings {
+		if poolutil.ContainsPool([]types.GenericInClusterPool{pool}, sibling) {
+			continue
+		}
+		siblingIPSet, err := poolutil.PoolSpecToIPSet(sibling.PoolSpec())
+		if err != nil {
+			continue
+		}
+		if poolIPSet.Overlaps(siblingIPSet) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "addresses"), pool.PoolSpec().Addresses,
+				fmt.Sprintf("addresses overlap with sibling %s %s", poolutil.PoolKind(sibling), poolName(sibling))))
+		}
+	}
+
+	return nil
+}
+
+// isAncestorOf reports whether ancestor is the parent of pool, or the parent
+// of one of the ancestors of pool. Sub-pools are children of the
+// GlobalInClusterIPPool they were carved out of.
+func (webhook *InClusterIPPool) isAncestorOf(ctx context.Context, ancestor, pool types.GenericInClusterPool) (bool, error) {
+	if poolutil.IsChildOf(pool, ancestor) || isSubPoolOf(pool, ancestor) {
+		return true, nil
+	}
+	ancestors, err := poolutil.Ancestors(ctx, webhook.Client, pool)
+	if err != nil {
+		return false, err
+	}
+	return poolutil.ContainsPool(ancestors, ancestor), nil
+}
+
+// isSubPoolOf reports whether pool was carved out of parent for a Cluster.
+func isSubPoolOf(pool, parent types.GenericInClusterPool) bool {
+	if _, ok := pool.(*v1alpha2.InClusterIPPool); !ok {
+		return false
+	}
+	if _, ok := parent.(*v1alpha2.GlobalInClusterIPPool); !ok {
+		return false
+	}
+	parentName, ok := pool.GetLabels()[v1alpha2.SubPoolOfLabel]
+	return ok && parentName == parent.GetName()
+}
+
+// poolName returns the name of a GlobalInClusterIPPool, or the namespace and
+// name of an InClusterIPPool.
+func poolName(pool client.Object) string {
+	if pool.GetNamespace() == "" {
+		return pool.GetName()
+	}
+	return client.ObjectKeyFromObject(pool).String()
+}
+
+// addressesChanged returns whether the addresses, prefix or allowOverlap of a
+// pool are changed by an update.
+func addressesChanged(oldPool, newPool types.GenericInClusterPool) bool {
+	oldSpec, newSpec := oldPool.PoolSpec(), newPool.PoolSpec()
+	return oldSpec.Prefix != newSpec.Prefix || oldSpec.AllowOverlap != newSpec.AllowOverlap || !slices.Equal(oldSpec.Addresses, newSpec.Addresses)
+}
+
+// gatewayOrSubnetChanged returns whether a pool is created, or its gateway,
+// addresses or prefix are changed by an update.
+func gatewayOrSubnetChanged(oldPool, newPool types.GenericInClusterPool) bool {
+	if oldPool == nil {
+		return true
+	}
+	oldSpec, newSpec := oldPool.PoolSpec(), newPool.PoolSpec()
+	return oldSpec.Gateway != newSpec.Gateway || oldSpec.Prefix != newSpec.Prefix || !slices.Equal(oldSpec.Addresses, newSpec.Addresses)
+}
+
+// validateGateway ensures that the gateway of a pool is within the subnet
+// inferred from its addresses, i.e. the peer address on a point-to-point
+// link. Pools of a single host are not checked, their gateway is always
+// outside of their subnet.
+func validateGateway(spec *v1alpha2.InClusterIPPoolSpec) field.ErrorList {
+	gateway, err := netip.ParseAddr(spec.Gateway)
+	if err != nil {
+		return nil
+	}
+	addressesIPSet, err := poolutil.AddressesToIPSet(spec.Addresses)
+	if err != nil || len(addressesIPSet.Ranges()) == 0 {
+		return nil
+	}
+
+	subnet := netip.PrefixFrom(addressesIPSet.Ranges()[0].From(), spec.Prefix).Masked()
+	if !subnet.IsValid() || subnet.Bits() == subnet.Addr().BitLen() || gateway.Is4() != subnet.Addr().Is4() {
+		return nil
+	}
+	if subnet.Contains(gateway) {
+		return nil
+	}
+	if poolutil.IsPointToPoint(subnet) {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "gateway"), spec.Gateway,
+			fmt.Sprintf("gateway must be the peer address within the point-to-point subnet %s", subnet))}
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "gateway"), spec.Gateway,
+		fmt.Sprintf("gateway must be within the subnet %s inferred from the addresses and prefix", subnet))}
+}
+
+func validateNetworkMetadata(metadata *v1alpha2.NetworkMetadata) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "networkMetadata")
+
+	for i, server := range metadata.DNSServers {
+		if _, err := netip.ParseAddr(server); err != nil {
+			errors = append(errors, field.Invalid(path.Child("dnsServers").Index(i), server, "provided DNS server is not a valid IP address"))
+		}
+	}
+
+	for i, domain := range metadata.SearchDomains {
+		if msgs := validation.IsDNS1123Subdomain(domain); len(msgs) > 0 {
+			errors = append(errors, field.Invalid(path.Child("searchDomains").Index(i), domain, strings.Join(msgs, ", ")))
+		}
+	}
+
+	for i, route := range metadata.Routes {
+		routePath := path.Child("routes").Index(i)
+		to, err := netip.ParsePrefix(route.To)
+		if err != nil {
+			errors = append(errors, field.Invalid(routePath.Child("to"), route.To, "provided destination is not a valid CIDR"))
+		}
+		via, err := netip.ParseAddr(route.Via)
+		if err != nil {
+			errors = append(errors, field.Invalid(routePath.Child("via"), route.Via, "provided next hop is not a valid IP address"))
+		}
+		if to.IsValid() && via.IsValid() && to.Addr().Is4() != via.Is4() {
+			errors = append(errors, field.Invalid(routePath, route, "provided destination and next hop are of mixed IP families"))
+		}
+	}
+
+	return errors
+}
+
+func validateAllowedNamespaces(pool types.GenericInClusterPool) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "allowedNamespaces")
+	allowed := pool.PoolSpec().AllowedNamespaces
+
+	if _, ok := pool.(*v1alpha2.GlobalInClusterIPPool); !ok {
+		return append(errors, field.Forbidden(path, "allowedNamespaces is only supported on GlobalInClusterIPPools"))
+	}
+
+	if allowed.Selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(allowed.Selector); err != nil {
+			errors = append(errors, field.Invalid(path.Child("selector"), allowed.Selector, err.Error()))
+		}
+	}
+
+	for i, name := range allowed.Names {
+		if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+			errors = append(errors, field.Invalid(path.Child("names").Index(i), name, strings.Join(msgs, ", ")))
+		}
+	}
+
+	return errors
+}
+
+func validateQuotas(quotas []v1alpha2.Quota) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "quotas")
+
+	for i, quota := range quotas {
+		quotaPath := path.Index(i)
+		if quota.Limit < 0 {
+			errors = append(errors, field.Invalid(quotaPath.Child("limit"), quota.Limit, "limit must not be negative"))
+		}
+
+		for j, value := range quota.Values {
+			valuePath := quotaPath.Child("values").Index(j)
+			switch quota.Key {
+			case v1alpha2.QuotaKeyNamespace:
+				if msgs := validation.IsDNS1123Label(value); len(msgs) > 0 {
+					errors = append(errors, field.Invalid(valuePath, value, strings.Join(msgs, ", ")))
+				}
+			case v1alpha2.QuotaKeyCluster:
+				namespace, name, found := strings.Cut(value, "/")
+				if !found || len(validation.IsDNS1123Label(namespace)) > 0 || len(validation.IsDNS1123Subdomain(name)) > 0 {
+					errors = append(errors, field.Invalid(valuePath, value, "provided value is not a Cluster in the form <namespace>/<name>"))
+				}
+			default:
+				errors = append(errors, field.NotSupported(quotaPath.Child("key"), quota.Key,
+					[]string{string(v1alpha2.QuotaKeyNamespace), string(v1alpha2.QuotaKeyCluster)}))
+			}
+		}
+	}
+
+	return errors
+}
+
+func validateClusterSubPools(pool types.GenericInClusterPool, isIPv6 bool) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "clusterSubPools")
+	subPools := pool.PoolSpec().ClusterSubPools
+
+	if _, ok := pool.(*v1alpha2.GlobalInClusterIPPool); !ok {
+		return append(errors, field.Forbidden(path, "clusterSubPools is only supported on GlobalInClusterIPPools"))
+	}
+
+	if _, err := metav1.LabelSelectorAsSelector(&subPools.ClusterSelector); err != nil {
+		errors = append(errors, field.Invalid(path.Child("clusterSelector"), subPools.ClusterSelector, err.Error()))
+	}
+
+	maxPrefix := 32
+	if isIPv6 {
+		maxPrefix = 128
+	}
+	if subPools.Prefix < pool.PoolSpec().Prefix || subPools.Prefix > maxPrefix {
+		errors = append(errors, field.Invalid(path.Child("prefix"), subPools.Prefix,
+			fmt.Sprintf("prefix must be between the prefix of the pool (%d) and %d", pool.PoolSpec().Prefix, maxPrefix)))
+	}
+
+	return errors
+}
+
+func validateOwnerCluster(oldPool, newPool types.GenericInClusterPool) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "ownerCluster")
+	ownerCluster := newPool.PoolSpec().OwnerCluster
+
+	if oldPool != nil && oldPool.PoolSpec().OwnerCluster != nil && !reflect.DeepEqual(oldPool.PoolSpec().OwnerCluster, ownerCluster) {
+		errors = append(errors, field.Forbidden(path, "ownerCluster cannot be changed once it is set"))
+	}
+
+	if ownerCluster == nil {
+		return errors
+	}
+
+	if _, ok := newPool.(*v1alpha2.InClusterIPPool); !ok {
+		return append(errors, field.Forbidden(path, "ownerCluster is only supported on InClusterIPPools"))
+	}
+
+	if msgs := validation.IsDNS1123Subdomain(ownerCluster.Name); len(msgs) > 0 {
+		errors = append(errors, field.Invalid(path.Child("name"), ownerCluster.Name, strings.Join(msgs, ", ")))
+	}
+
+	return errors
+}
+
+func validateAddressSubsets(spec *v1alpha2.InClusterIPPoolSpec, poolIPSet *netipx.IPSet) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "addressSubsets")
+
+	if spec.FailureDomainLabel != "" {
+		if msgs := validation.IsQualifiedName(spec.FailureDomainLabel); len(msgs) > 0 {
+			errors = append(errors, field.Invalid(field.NewPath("spec", "failureDomainLabel"), spec.FailureDomainLabel, strings.Join(msgs, ", ")))
+		}
+	}
+
+	for i, subset := range spec.AddressSubsets {
+		subsetPath := path.Index(i)
+		if len(subset.Addresses) == 0 {
+			errors = append(errors, field.Required(subsetPath.Child("addresses"), "addresses is required"))
+		}
+		errors = append(errors, validateAddressesWithinPool(subsetPath.Child("addresses"), subset.Addresses, poolIPSet)...)
+
+		if len(subset.Labels) == 0 {
+			errors = append(errors, field.Required(subsetPath.Child("labels"), "labels is required"))
+		}
+		for key, value := range subset.Labels {
+			if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+				errors = append(errors, field.Invalid(subsetPath.Child("labels"), key, strings.Join(msgs, ", ")))
+			}
+			if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+				errors = append(errors, field.Invalid(subsetPath.Child("labels").Key(key), value, strings.Join(msgs, ", ")))
+			}
+		}
+	}
+
+	return errors
+}
+
+func validatePurposeRanges(spec *v1alpha2.InClusterIPPoolSpec, poolIPSet *netipx.IPSet) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "purposeRanges")
+
+	names := map[string]bool{}
+	var purposes []string
+	purposeIPSets := map[string]*netipx.IPSet{}
+	for i, purposeRange := range spec.PurposeRanges {
+		rangePath := path.Index(i)
+		if names[purposeRange.Name] {
+			errors = append(errors, field.Duplicate(rangePath.Child("name"), purposeRange.Name))
+		}
+		names[purposeRange.Name] = true
+
+		if msgs := validation.IsValidLabelValue(purposeRange.Purpose); len(msgs) > 0 || purposeRange.Purpose == "" {
+			errors = append(errors, field.Invalid(rangePath.Child("purpose"), purposeRange.Purpose, "purpose must be a non-empty label value"))
+		}
+
+		if len(purposeRange.Addresses) == 0 {
+			errors = append(errors, field.Required(rangePath.Child("addresses"), "addresses is required"))
+		}
+		errors = append(errors, validateAddressesWithinPool(rangePath.Child("addresses"), purposeRange.Addresses, poolIPSet)...)
+		rangeIPSet, err := poolutil.AddressesToIPSet(purposeRange.Addresses)
+		if err != nil {
+			continue
+		}
+
+		for _, purpose := range purposes {
+			if purpose != purposeRange.Purpose && rangeIPSet.Overlaps(purposeIPSets[purpose]) {
+				errors = append(errors, field.Invalid(rangePath.Child("addresses"), purposeRange.Addresses,
+					fmt.Sprintf("addresses overlap with the ranges of purpose %s", purpose)))
+			}
+		}
+		if other, ok := purposeIPSets[purposeRange.Purpose]; ok {
+			builder := &netipx.IPSetBuilder{}
+			builder.AddSet(rangeIPSet)
+			builder.AddSet(other)
+			if rangeIPSet, err = builder.IPSet(); err != nil {
+				continue
+			}
+		} else {
+			purposes = append(purposes, purposeRange.Purpose)
+		}
+		purposeIPSets[purposeRange.Purpose] = rangeIPSet
+	}
+
+	return errors
+}
+
+func validateReservedAddresses(spec *v1alpha2.InClusterIPPoolSpec, poolIPSet *netipx.IPSet) field.ErrorList {
+	var errors field.ErrorList
+	path := field.NewPath("spec", "reservedAddresses")
+
+	for i, reserved := range spec.ReservedAddresses {
+		reservedPath := path.Index(i)
+		if len(reserved.Addresses) == 0 {
+			errors = append(errors, field.Required(reservedPath.Child("addresses"), "addresses is required"))
+		}
+		errors = append(errors, validateAddressesWithinPool(reservedPath.Child("addresses"), reserved.Addresses, poolIPSet)...)
+	}
+
+	return errors
+}
+
+// validateAddressesWithinPool checks that every address is valid and within
+// the addresses of the pool.
+func validateAddressesWithinPool(path *field.Path, addresses []string, poolIPSet *netipx.IPSet) field.ErrorList {
+	var errors field.ErrorList
+	for i, address := range addresses {
+		ipSet, err := poolutil.AddressToIPSet(address)
+		if err != nil {
+			errors = append(errors, invalidAddress(path.Index(i), address, err))
+			continue
+		}
+		if !poolIPSet.ContainsRange(ipSet.Ranges()[0]) {
+			errors = append(errors, field.Invalid(path.Index(i), address, "provided address is not within the addresses of the pool"))
+		}
+	}
+	return errors
+}
+
+func validateReservedAddressPolicy(spec *v1alpha2.InClusterIPPoolSpec, is4 bool) field.ErrorList {
+	policy := spec.ReservedAddressPolicy
+	if policy == nil {
+		return nil
+	}
+
+	var errors field.ErrorList
+	policyPath := field.NewPath("spec", "reservedAddressPolicy")
+	if policy.SubnetBoundaries != nil && *policy.SubnetBoundaries && spec.AllocateReservedIPAddresses {
+		errors = append(errors, field.Invalid(policyPath.Child("subnetBoundaries"), *policy.SubnetBoundaries, "subnetBoundaries conflicts with allocateReservedIPAddresses"))
+	}
+	if policy.FirstAddresses < 0 || policy.FirstAddresses > 256 {
+		errors = append(errors, field.Invalid(policyPath.Child("firstAddresses"), policy.FirstAddresses, "firstAddresses must be between 0 and 256"))
+	}
+	if policy.OctetBoundaries && !is4 {
+		errors = append(errors, field.Invalid(policyPath.Child("octetBoundaries"), policy.OctetBoundaries, "octetBoundaries only applies to IPv4 pools"))
+	}
+	if policy.SubnetAnycast && is4 {
+		errors = append(errors, field.Invalid(policyPath.Child("subnetAnycast"), policy.SubnetAnycast, "subnetAnycast only applies to IPv6 pools"))
+	}
+	// The policies are applied to every block of the pool, which is bounded
+	// by its prefix.
+	if bits, ok := poolutil.PolicyBlockBits(policy, is4); ok && spec.Prefix < bits-poolutil.MaxPolicyBlockBits {
+		errors = append(errors, field.Invalid(field.NewPath("spec", "prefix"), spec.Prefix,
+			fmt.Sprintf("prefix must be at least %d to reserve addresses in every /%d", bits-poolutil.MaxPolicyBlockBits, bits)))
+	}
+
+	return errors
+}
+
+// invalidAddress reports an address that ParseAddressRange can't parse,
+// including the reason.
+func invalidAddress(path *field.Path, address string, err error) *field.Error {
+	return field.Invalid(path, address, fmt.Sprintf("provided address is not a valid IP, range, nor CIDR: %v", err))
+}
+
 func validatePrefix(spec *v1alpha2.InClusterIPPoolSpec) (*netipx.IPSet, field.ErrorList) {
 	var errors field.ErrorList
 
@@ -283,16 +1004,16 @@ func validateAddressesAreWithinPrefix(spec *v1alpha2.InClusterIPPoolSpec) field.
 		return prefixErrs
 	}
 
-	for _, addressStr := range spec.Addresses {
+	for i, addressStr := range spec.Addresses {
 		addressIPSet, err := poolutil.AddressToIPSet(addressStr)
 		if err != nil {
 			// this should never occur, previous validations will have caught this.
-			errors = append(errors, field.Invalid(field.NewPath("spec", "addresses"), addressStr, "provided address is not a valid IP, range, nor CIDR"))
+			errors = append(errors, invalidAddress(field.NewPath("spec", "addresses").Index(i), addressStr, err))
 			continue
 		}
 		// We know that each addressIPSet should be made up of only one range, it came from a single addressStr
 		if !prefixIPSet.ContainsRange(addressIPSet.Ranges()[0]) {
-			errors = append(errors, field.Invalid(field.NewPath("spec", "addresses"), addressStr, "provided address belongs to a different subnet than others"))
+			errors = append(errors, field.Invalid(field.NewPath("spec", "addresses").Index(i), addressStr, "provided address belongs to a different subnet than others"))
 			continue
 		}
 	}
@@ -40,6 +40,7 @@ func TestPoolDeletionWithExistingIPAddresses(t *testing.T) {
 
 	scheme := runtime.NewScheme()
 	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
 
 	namespacedPool := &v1alpha2.InClusterIPPool{
 		ObjectMeta: metav1.ObjectMeta{
@@ -92,6 +93,7 @@ func TestUpdatingPoolInUseAddresses(t *testing.T) {
 
 	scheme := runtime.NewScheme()
 	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
 
 	namespacedPool := &v1alpha2.InClusterIPPool{
 		ObjectMeta: metav1.ObjectMeta{
@@ -118,12 +120,14 @@ func TestUpdatingPoolInUseAddresses(t *testing.T) {
 	ips := []client.Object{
 		createIP("my-ip", "10.0.0.10", namespacedPool),
 		createIP("my-ip-2", "10.0.0.10", globalPool),
+		createIP("my-gateway-ip", "10.0.0.1", namespacedPool),
 	}
 
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(ips...).
 		WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+		WithIndex(&ipamv1.IPAddressClaim{}, index.IPAddressClaimPoolRefCombinedField, index.IPAddressClaimByCombinedPoolRef).
 		Build()
 
 	webhook := InClusterIPPool{
@@ -137,6 +141,143 @@ func TestUpdatingPoolInUseAddresses(t *testing.T) {
 
 	g.Expect(webhook.ValidateUpdate(ctx, oldNamespacedPool, namespacedPool)).Error().NotTo(BeNil(), "should not allow removing in use IPs from addresses field in pool")
 	g.Expect(webhook.ValidateUpdate(ctx, oldGlobalPool, globalPool)).Error().NotTo(BeNil(), "should not allow removing in use IPs from addresses field in pool")
+
+	namespacedPool.Spec.Addresses = []string{"10.0.0.10-10.0.0.20"}
+	namespacedPool.Spec.ExcludedAddresses = []string{"10.0.0.10"}
+	globalPool.Spec.Addresses = []string{"10.0.0.10-10.0.0.20"}
+	globalPool.Spec.ExcludedAddresses = []string{"10.0.0.10"}
+
+	g.Expect(webhook.ValidateUpdate(ctx, oldNamespacedPool, namespacedPool)).To(
+		ConsistOf(ContainSubstring("will be retired once released")), "should allow retiring in use IPs by excluding them")
+	g.Expect(webhook.ValidateUpdate(ctx, oldGlobalPool, globalPool)).To(
+		ConsistOf(ContainSubstring("will be retired once released")), "should allow retiring in use IPs by excluding them")
+
+	namespacedPool.Spec.ExcludedAddresses = nil
+	g.Expect(webhook.ValidateUpdate(ctx, oldNamespacedPool, namespacedPool)).Error().To(Succeed(), "should allow the gateway to be allocated")
+}
+
+func TestPoolWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     v1alpha2.InClusterIPPoolSpec
+		warnings []string
+	}{
+		{
+			name: "excluded addresses outside of the pool",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:         []string{"10.0.0.0/24"},
+				Prefix:            24,
+				Gateway:           "10.0.0.1",
+				ExcludedAddresses: []string{"10.0.0.5", "10.0.1.0/24"},
+			},
+			warnings: []string{"spec.excludedAddresses[1]: 10.0.1.0/24 is not within the addresses of the pool and has no effect"},
+		},
+		{
+			name: "gateway listed in addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.1", "10.0.0.10-20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+			},
+			warnings: []string{"spec.addresses[0]: the gateway 10.0.0.1 is never allocated to regular claims"},
+		},
+		{
+			name: "pool with few usable addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:         []string{"10.0.0.10-13"},
+				Prefix:            24,
+				Gateway:           "10.0.0.1",
+				ExcludedAddresses: []string{"10.0.0.13"},
+			},
+			warnings: []string{"the pool has only 3 usable addresses"},
+		},
+		{
+			name: "point-to-point pool",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/31"},
+				Prefix:    31,
+			},
+		},
+		{
+			name: "pool without suspicious configuration",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/24"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(webhook.ValidateCreate(ctx, &v1alpha2.InClusterIPPool{Spec: tt.spec})).To(ConsistOf(tt.warnings))
+			g.Expect(webhook.ValidateCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: tt.spec})).To(ConsistOf(tt.warnings))
+		})
+	}
+}
+
+func TestShrinkingPoolBelowDemand(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	pool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-pool",
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    24,
+			Gateway:   "10.0.0.1",
+		},
+	}
+
+	var claims []client.Object
+	for _, name := range []string{"claim-1", "claim-2", "claim-3", "claim-4", "claim-5"} {
+		claims = append(claims, &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: ipamv1.IPAddressClaimSpec{
+				PoolRef: corev1.TypedLocalObjectReference{
+					Kind: pool.GetObjectKind().GroupVersionKind().Kind,
+					Name: pool.GetName(),
+				},
+			},
+		})
+	}
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(claims...).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			WithIndex(&ipamv1.IPAddressClaim{}, index.IPAddressClaimPoolRefCombinedField, index.IPAddressClaimByCombinedPoolRef).
+			Build(),
+	}
+
+	oldPool := pool.DeepCopy()
+	pool.Spec.Addresses = []string{"10.0.0.10-10.0.0.15"}
+	g.Expect(webhook.ValidateUpdate(ctx, oldPool, pool)).To(BeEmpty(), "should not warn while the pool serves all claims")
+
+	pool.Spec.Addresses = []string{"10.0.0.10-10.0.0.13"}
+	g.Expect(webhook.ValidateUpdate(ctx, oldPool, pool)).To(
+		ConsistOf("the pool has 4 usable addresses, fewer than the 5 claims referencing it"))
+
+	g.Expect(webhook.ValidateUpdate(ctx, pool, pool)).To(BeEmpty(), "should only warn when the pool shrinks")
 }
 
 func TestDeleteSkip(t *testing.T) {
@@ -144,6 +285,7 @@ func TestDeleteSkip(t *testing.T) {
 
 	scheme := runtime.NewScheme()
 	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
 
 	namespacedPool := &v1alpha2.InClusterIPPool{
 		ObjectMeta: metav1.ObjectMeta{
@@ -192,6 +334,56 @@ func TestDeleteSkip(t *testing.T) {
 	g.Expect(webhook.ValidateDelete(ctx, globalPool)).Error().To(BeNil())
 }
 
+func TestDeleteCascade(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	namespacedPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-pool",
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses:      []string{"10.0.0.10-10.0.0.20"},
+			Prefix:         24,
+			Gateway:        "10.0.0.1",
+			DeletionPolicy: v1alpha2.DeletionPolicyCascade,
+		},
+	}
+
+	globalPool := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-pool",
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses:      []string{"10.0.0.10-10.0.0.20"},
+			Prefix:         24,
+			Gateway:        "10.0.0.1",
+			DeletionPolicy: v1alpha2.DeletionPolicyCascade,
+		},
+	}
+
+	ips := []client.Object{
+		createIP("my-ip", "", namespacedPool),
+		createIP("my-ip-2", "", globalPool),
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ips...).
+		WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+		Build()
+
+	webhook := InClusterIPPool{
+		Client: fakeClient,
+	}
+
+	g.Expect(webhook.ValidateDelete(ctx, namespacedPool)).Error().To(BeNil(), "should allow deletion when the pool cascades")
+	g.Expect(webhook.ValidateDelete(ctx, globalPool)).Error().To(BeNil(), "should allow deletion when the pool cascades")
+}
+
 func TestInClusterIPPoolDefaulting(t *testing.T) {
 	g := NewWithT(t)
 
@@ -268,6 +460,33 @@ func TestInClusterIPPoolDefaulting(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "addresses with network metadata",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Gateway:   "10.0.0.1",
+				Prefix:    24,
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					DNSServers:    []string{"10.0.0.2", "fd00::2"},
+					SearchDomains: []string{"example.com"},
+					MTU:           9000,
+					VLANID:        100,
+					Routes:        []v1alpha2.Route{{To: "10.1.0.0/16", Via: "10.0.0.254", Metric: 100}},
+				},
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Gateway:   "10.0.0.1",
+				Prefix:    24,
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					DNSServers:    []string{"10.0.0.2", "fd00::2"},
+					SearchDomains: []string{"example.com"},
+					MTU:           9000,
+					VLANID:        100,
+					Routes:        []v1alpha2.Route{{To: "10.1.0.0/16", Via: "10.0.0.254", Metric: 100}},
+				},
+			},
+		},
 		{
 			name: "IPv6 addresses with gateway and prefix",
 			spec: v1alpha2.InClusterIPPoolSpec{
@@ -337,6 +556,151 @@ func TestInClusterIPPoolDefaulting(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "IPv4 point-to-point pool with its peer as gateway",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/31"},
+				Prefix:    31,
+				Gateway:   "10.0.0.0",
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/31"},
+				Prefix:    31,
+				Gateway:   "10.0.0.0",
+			},
+		},
+		{
+			name: "IPv4 host pool with a gateway outside of its subnet",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.5"},
+				Prefix:    32,
+				Gateway:   "169.254.0.1",
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.5"},
+				Prefix:    32,
+				Gateway:   "169.254.0.1",
+			},
+		},
+		{
+			name: "IPv6 pool with a reserved address policy",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"fd01::/64"},
+				Prefix:                64,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true, FirstAddresses: 3},
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"fd01::/64"},
+				Prefix:                64,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true, FirstAddresses: 3},
+			},
+		},
+		{
+			name: "IPv6 point-to-point pool",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"fd01::/127"},
+				Prefix:    127,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"fd01::/127"},
+				Prefix:    127,
+			},
+		},
+		{
+			name: "single CIDR without prefix",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/24"},
+				Gateway:   "10.0.0.1",
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/24"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+			},
+		},
+		{
+			name: "IPv4 pool with the first usable address as gateway",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.0/24"},
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.0/24"},
+				Prefix:        24,
+				Gateway:       "10.0.0.1",
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+		},
+		{
+			name: "IPv4 pool with the last usable address as gateway",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.10-10.0.0.20"},
+				Prefix:        24,
+				GatewayPolicy: v1alpha2.GatewayPolicyLastUsable,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.10-10.0.0.20"},
+				Prefix:        24,
+				Gateway:       "10.0.0.254",
+				GatewayPolicy: v1alpha2.GatewayPolicyLastUsable,
+			},
+		},
+		{
+			name: "IPv6 pool with the last usable address as gateway",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"fd01::/120"},
+				GatewayPolicy: v1alpha2.GatewayPolicyLastUsable,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"fd01::/120"},
+				Prefix:        120,
+				Gateway:       "fd01::ff",
+				GatewayPolicy: v1alpha2.GatewayPolicyLastUsable,
+			},
+		},
+		{
+			name: "addresses with shortened ranges and netmasks",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:         []string{"10.0.0.10-50", "10.0.0.100-10.0.0.110"},
+				Prefix:            24,
+				Gateway:           "10.0.0.1",
+				ExcludedAddresses: []string{"10.0.0.40/255.255.255.248"},
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:         []string{"10.0.0.10-50", "10.0.0.100-10.0.0.110"},
+				Prefix:            24,
+				Gateway:           "10.0.0.1",
+				ExcludedAddresses: []string{"10.0.0.40/255.255.255.248"},
+			},
+		},
+		{
+			name: "offsets within a CIDR without prefix",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"fd01::/64[16-255]"},
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"fd01::/64[16-255]"},
+				Prefix:        64,
+				Gateway:       "fd01::1",
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+		},
+		{
+			name: "pool with a gateway policy and an explicit gateway",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.0/24"},
+				Prefix:        24,
+				Gateway:       "10.0.0.100",
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+			expect: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.0/24"},
+				Prefix:        24,
+				Gateway:       "10.0.0.100",
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -345,6 +709,7 @@ func TestInClusterIPPoolDefaulting(t *testing.T) {
 
 		scheme := runtime.NewScheme()
 		g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+		g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
 		webhook := InClusterIPPool{
 			Client: fake.NewClientBuilder().
 				WithScheme(scheme).
@@ -628,6 +993,174 @@ func TestInvalidScenarios(t *testing.T) {
 			},
 			expectedError: "addresses and excluded addresses are of mixed IP families",
 		},
+		{
+			testcase: "DNS servers must be valid IP addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					DNSServers: []string{"10.0.0.2", "dns.example.com"},
+				},
+			},
+			expectedError: "spec.networkMetadata.dnsServers[1]: Invalid value: \"dns.example.com\": provided DNS server is not a valid IP address",
+		},
+		{
+			testcase: "search domains must be valid DNS names",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					SearchDomains: []string{"example.com", "not_a_domain"},
+				},
+			},
+			expectedError: "spec.networkMetadata.searchDomains[1]",
+		},
+		{
+			testcase: "route destinations must be valid CIDRs",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					Routes: []v1alpha2.Route{{To: "10.1.0.0", Via: "10.0.0.1"}},
+				},
+			},
+			expectedError: "provided destination is not a valid CIDR",
+		},
+		{
+			testcase: "route next hops must be valid IP addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					Routes: []v1alpha2.Route{{To: "10.1.0.0/16", Via: "gateway"}},
+				},
+			},
+			expectedError: "provided next hop is not a valid IP address",
+		},
+		{
+			testcase: "routes must not mix IP families",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				NetworkMetadata: &v1alpha2.NetworkMetadata{
+					Routes: []v1alpha2.Route{{To: "fd00::/64", Via: "10.0.0.1"}},
+				},
+			},
+			expectedError: "provided destination and next hop are of mixed IP families",
+		},
+		{
+			testcase: "namespace quota values must be namespaces",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				Quotas: []v1alpha2.Quota{
+					{Key: v1alpha2.QuotaKeyNamespace, Values: []string{"Not_A_Namespace"}, Limit: 2},
+				},
+			},
+			expectedError: "spec.quotas[0].values[0]",
+		},
+		{
+			testcase: "cluster quota values must contain the namespace",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				Quotas: []v1alpha2.Quota{
+					{Key: v1alpha2.QuotaKeyCluster, Values: []string{"my-cluster"}, Limit: 2},
+				},
+			},
+			expectedError: "provided value is not a Cluster in the form <namespace>/<name>",
+		},
+		{
+			testcase: "quota limits must not be negative",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.0.1",
+				Quotas: []v1alpha2.Quota{
+					{Key: v1alpha2.QuotaKeyNamespace, Limit: -1},
+				},
+			},
+			expectedError: "limit must not be negative",
+		},
+		{
+			testcase: "reserving the subnet boundaries conflicts with allocateReservedIPAddresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:                   []string{"10.0.0.0/24"},
+				Prefix:                      24,
+				AllocateReservedIPAddresses: true,
+				ReservedAddressPolicy:       &v1alpha2.ReservedAddressPolicy{SubnetBoundaries: ptr.To(true)},
+			},
+			expectedError: "subnetBoundaries conflicts with allocateReservedIPAddresses",
+		},
+		{
+			testcase: "the number of reserved first addresses is limited",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"10.0.0.0/16"},
+				Prefix:                16,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{FirstAddresses: 257},
+			},
+			expectedError: "firstAddresses must be between 0 and 256",
+		},
+		{
+			testcase: "octet boundaries cannot be reserved in IPv6 pools",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"fd01::/120"},
+				Prefix:                120,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{OctetBoundaries: true},
+			},
+			expectedError: "octetBoundaries only applies to IPv4 pools",
+		},
+		{
+			testcase: "subnet anycast addresses cannot be reserved in IPv4 pools",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"10.0.0.0/24"},
+				Prefix:                24,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true},
+			},
+			expectedError: "subnetAnycast only applies to IPv6 pools",
+		},
+		{
+			testcase: "subnet anycast addresses cannot be reserved in very large pools",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:             []string{"fd01::/120"},
+				Prefix:                32,
+				ReservedAddressPolicy: &v1alpha2.ReservedAddressPolicy{SubnetAnycast: true},
+			},
+			expectedError: "prefix must be at least 48 to reserve addresses in every /64",
+		},
+		{
+			testcase: "invalid addresses are reported with their index and reason",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-50", "10.0.0.60-20"},
+				Prefix:    24,
+			},
+			expectedError: `spec.addresses[1]: Invalid value: "10.0.0.60-20": provided address is not a valid IP, range, nor CIDR: range start 10.0.0.60 is after range end 10.0.0.20`,
+		},
+		{
+			testcase: "invalid offsets in excluded addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:         []string{"10.0.0.0/24"},
+				Prefix:            24,
+				ExcludedAddresses: []string{"10.0.0.0/24[200-300]"},
+			},
+			expectedError: `spec.excludedAddresses[0]: Invalid value: "10.0.0.0/24[200-300]": provided address is not a valid IP, range, nor CIDR: offset 300 is outside of 10.0.0.0/24`,
+		},
+		{
+			testcase: "a gateway cannot be derived for a single host pool",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses:     []string{"10.0.0.5"},
+				Prefix:        32,
+				GatewayPolicy: v1alpha2.GatewayPolicyFirstUsable,
+			},
+			expectedError: "no gateway can be derived from the addresses and prefix",
+		},
 	}
 	for _, tt := range tests {
 		namespacedPool := &v1alpha2.InClusterIPPool{Spec: tt.spec}
@@ -636,6 +1169,7 @@ func TestInvalidScenarios(t *testing.T) {
 		g := NewWithT(t)
 		scheme := runtime.NewScheme()
 		g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+		g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
 
 		webhook := InClusterIPPool{
 			Client: fake.NewClientBuilder().
@@ -648,6 +1182,569 @@ func TestInvalidScenarios(t *testing.T) {
 	}
 }
 
+// TestGatewayOutsideOfSubnet ensures that a gateway outside of the subnet is
+// rejected when a pool is created or its gateway or subnet change, but that
+// pools accepted before the check existed can still be updated otherwise.
+func TestGatewayOutsideOfSubnet(t *testing.T) {
+	tests := []invalidScenarioTest{
+		{
+			testcase: "the gateway of a point-to-point pool must be its peer address",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.0/31"},
+				Prefix:    31,
+				Gateway:   "10.0.0.2",
+			},
+			expectedError: "gateway must be the peer address within the point-to-point subnet 10.0.0.0/31",
+		},
+		{
+			testcase: "the gateway of an IPv6 point-to-point pool must be its peer address",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"fd01::1"},
+				Prefix:    127,
+				Gateway:   "fd01::2",
+			},
+			expectedError: "gateway must be the peer address within the point-to-point subnet fd01::/127",
+		},
+		{
+			testcase: "the gateway must be within the subnet of the addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"10.0.0.10-10.0.0.20"},
+				Prefix:    24,
+				Gateway:   "10.0.1.1",
+			},
+			expectedError: "gateway must be within the subnet 10.0.0.0/24 inferred from the addresses and prefix",
+		},
+		{
+			testcase: "the gateway of an IPv6 pool must be within the subnet of the addresses",
+			spec: v1alpha2.InClusterIPPoolSpec{
+				Addresses: []string{"fd01::/64"},
+				Prefix:    64,
+				Gateway:   "fd02::1",
+			},
+			expectedError: "gateway must be within the subnet fd01::/64 inferred from the addresses and prefix",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	NewWithT(t).Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			WithIndex(&ipamv1.IPAddressClaim{}, index.IPAddressClaimPoolRefCombinedField, index.IPAddressClaimByCombinedPoolRef).
+			Build(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			g := NewWithT(t)
+			pool := &v1alpha2.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+
+			_, err := webhook.ValidateCreate(ctx, pool)
+			g.Expect(err).To(MatchError(ContainSubstring(tt.expectedError)))
+
+			withFinalizer := pool.DeepCopy()
+			withFinalizer.Finalizers = []string{"ipam.cluster.x-k8s.io/ProtectPool"}
+			_, err = webhook.ValidateUpdate(ctx, pool, withFinalizer)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			withoutGateway := pool.DeepCopy()
+			withoutGateway.Spec.Gateway = ""
+			_, err = webhook.ValidateUpdate(ctx, withoutGateway, pool)
+			g.Expect(err).To(MatchError(ContainSubstring(tt.expectedError)))
+		})
+	}
+}
+
+func TestAllowedNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	spec := func(allowed *v1alpha2.AllowedNamespaces) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses:         []string{"10.0.0.10-10.0.0.20"},
+			Prefix:            24,
+			Gateway:           "10.0.0.1",
+			AllowedNamespaces: allowed,
+		}
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec(&v1alpha2.AllowedNamespaces{
+		Names:    []string{"tenant-a"},
+		Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}},
+	})}, &webhook)).Error().To(Succeed(), "should allow allowedNamespaces on a GlobalInClusterIPPool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(&v1alpha2.AllowedNamespaces{
+		Names: []string{"tenant-a"},
+	})}, &webhook)).Error().To(MatchError(ContainSubstring("allowedNamespaces is only supported on GlobalInClusterIPPools")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec(&v1alpha2.AllowedNamespaces{
+		Names: []string{"Not_A_Namespace"},
+	})}, &webhook)).Error().To(MatchError(ContainSubstring("spec.allowedNamespaces.names[0]")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec(&v1alpha2.AllowedNamespaces{
+		Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "tier",
+			Operator: "Unknown",
+		}}},
+	})}, &webhook)).Error().To(MatchError(ContainSubstring("spec.allowedNamespaces.selector")))
+}
+
+func TestClusterSubPools(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	spec := func(prefix int) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.0/16"},
+			Prefix:    16,
+			Gateway:   "10.0.0.1",
+			ClusterSubPools: &v1alpha2.ClusterSubPools{
+				ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"ipam": "carve"}},
+				Prefix:          prefix,
+			},
+		}
+	}
+
+	globalPool := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "supernet"},
+		Spec:       spec(24),
+	}
+	subPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "my-cluster-supernet",
+			Labels:    map[string]string{v1alpha2.SubPoolOfLabel: "supernet"},
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.1.0/24"},
+			Prefix:    16,
+			Gateway:   "10.0.0.1",
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(subPool).
+		WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+		Build()
+	webhook := InClusterIPPool{
+		Client: fakeClient,
+	}
+
+	g.Expect(testCreate(ctx, globalPool, &webhook)).Error().To(Succeed(), "should allow clusterSubPools on a GlobalInClusterIPPool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(24)}, &webhook)).Error().To(
+		MatchError(ContainSubstring("clusterSubPools is only supported on GlobalInClusterIPPools")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec(8)}, &webhook)).Error().To(
+		MatchError(ContainSubstring("prefix must be between the prefix of the pool (16) and 32")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec(64)}, &webhook)).Error().To(
+		MatchError(ContainSubstring("prefix must be between the prefix of the pool (16) and 32")))
+
+	shrunkPool := globalPool.DeepCopy()
+	shrunkPool.Spec.Addresses = []string{"10.0.2.0-10.0.255.255"}
+	g.Expect(webhook.ValidateUpdate(ctx, globalPool, shrunkPool)).Error().NotTo(Succeed(), "should not allow removing the addresses of sub-pools from the pool")
+
+	g.Expect(webhook.ValidateDelete(ctx, globalPool)).Error().NotTo(Succeed(), "should not allow deletion while sub-pools exist")
+
+	g.Expect(fakeClient.Delete(ctx, subPool)).To(Succeed())
+	g.Expect(webhook.ValidateDelete(ctx, globalPool)).Error().To(Succeed(), "should allow deletion without sub-pools")
+}
+
+func TestOwnerCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	spec := func(ownerCluster string) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses:    []string{"10.0.0.10-10.0.0.20"},
+			Prefix:       24,
+			Gateway:      "10.0.0.1",
+			OwnerCluster: &corev1.LocalObjectReference{Name: ownerCluster},
+		}
+	}
+
+	pool := &v1alpha2.InClusterIPPool{Spec: spec("my-cluster")}
+	g.Expect(testCreate(ctx, pool, &webhook)).Error().To(Succeed(), "should allow ownerCluster on an InClusterIPPool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{Spec: spec("my-cluster")}, &webhook)).Error().To(
+		MatchError(ContainSubstring("ownerCluster is only supported on InClusterIPPools")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec("")}, &webhook)).Error().To(
+		MatchError(ContainSubstring("spec.ownerCluster.name")))
+
+	changed := pool.DeepCopy()
+	changed.Spec.OwnerCluster.Name = "other-cluster"
+	g.Expect(webhook.ValidateUpdate(ctx, pool, changed)).Error().To(
+		MatchError(ContainSubstring("ownerCluster cannot be changed once it is set")))
+
+	unbound := pool.DeepCopy()
+	unbound.Spec.OwnerCluster = nil
+	g.Expect(webhook.ValidateUpdate(ctx, unbound, pool)).Error().To(Succeed(), "should allow binding a pool to a cluster")
+}
+
+func TestAddressSubsets(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	spec := func(subsets ...v1alpha2.AddressSubset) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses:      []string{"10.0.0.10-10.0.0.40"},
+			Prefix:         24,
+			Gateway:        "10.0.0.1",
+			AddressSubsets: subsets,
+		}
+	}
+	zone := func(value string, addresses ...string) v1alpha2.AddressSubset {
+		return v1alpha2.AddressSubset{Addresses: addresses, Labels: map[string]string{"topology.kubernetes.io/zone": value}}
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(zone("a", "10.0.0.10-10.0.0.19"), zone("b", "10.0.0.20/30"))}, &webhook)).Error().To(
+		Succeed(), "should allow address subsets within the pool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(zone("a", "10.0.0.30-10.0.0.50"))}, &webhook)).Error().To(
+		MatchError(ContainSubstring("provided address is not within the addresses of the pool")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(zone("a", "10.0.0.300"))}, &webhook)).Error().To(
+		MatchError(ContainSubstring("provided address is not a valid IP, range, nor CIDR")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(zone("not a value", "10.0.0.10"))}, &webhook)).Error().To(
+		MatchError(ContainSubstring("spec.addressSubsets[0].labels[topology.kubernetes.io/zone]")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(v1alpha2.AddressSubset{Addresses: []string{"10.0.0.10"}})}, &webhook)).Error().To(
+		MatchError(ContainSubstring("labels is required")))
+
+	invalidLabel := spec(zone("a", "10.0.0.10"))
+	invalidLabel.FailureDomainLabel = "not/a/label"
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: invalidLabel}, &webhook)).Error().To(
+		MatchError(ContainSubstring("spec.failureDomainLabel")))
+}
+
+func TestPurposeRanges(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	spec := func(ranges ...v1alpha2.PurposeRange) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses:     []string{"10.0.0.10-10.0.0.40"},
+			Prefix:        24,
+			Gateway:       "10.0.0.1",
+			PurposeRanges: ranges,
+		}
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.10-10.0.0.14"}},
+		v1alpha2.PurposeRange{Name: "more-vips", Purpose: "vip", Addresses: []string{"10.0.0.12-10.0.0.16"}},
+		v1alpha2.PurposeRange{Name: "control-plane", Purpose: "control-plane", Addresses: []string{"10.0.0.20/30"}},
+	)}, &webhook)).Error().To(Succeed(), "should allow purpose ranges within the pool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.30-10.0.0.50"}},
+	)}, &webhook)).Error().To(MatchError(ContainSubstring("provided address is not within the addresses of the pool")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.10"}},
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.11"}},
+	)}, &webhook)).Error().To(MatchError(ContainSubstring("spec.purposeRanges[1].name: Duplicate value")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "not a purpose", Addresses: []string{"10.0.0.10"}},
+	)}, &webhook)).Error().To(MatchError(ContainSubstring("purpose must be a non-empty label value")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec(
+		v1alpha2.PurposeRange{Name: "vips", Purpose: "vip", Addresses: []string{"10.0.0.10-10.0.0.20"}},
+		v1alpha2.PurposeRange{Name: "control-plane", Purpose: "control-plane", Addresses: []string{"10.0.0.20-10.0.0.30"}},
+	)}, &webhook)).Error().To(MatchError(ContainSubstring("addresses overlap with the ranges of purpose vip")))
+}
+
+func TestReservedAddresses(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	spec := func(addresses ...string) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.40"},
+			Prefix:    24,
+			Gateway:   "10.0.0.1",
+			ReservedAddresses: []v1alpha2.ReservedAddress{
+				{Addresses: addresses, Owner: "network", Description: "switches"},
+			},
+		}
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec("10.0.0.10", "10.0.0.20/31")}, &webhook)).Error().To(
+		Succeed(), "should allow reserved addresses within the pool")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec("10.0.0.2")}, &webhook)).Error().To(
+		MatchError(ContainSubstring("provided address is not within the addresses of the pool")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec("10.0.0.300")}, &webhook)).Error().To(
+		MatchError(ContainSubstring("provided address is not a valid IP, range, nor CIDR")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{Spec: spec()}, &webhook)).Error().To(
+		MatchError(ContainSubstring("spec.reservedAddresses[0].addresses: Required value")))
+}
+
+func TestOverlappingPools(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	spec := func(addresses string, allowOverlap bool) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses:    []string{addresses},
+			Prefix:       24,
+			Gateway:      "10.0.0.1",
+			AllowOverlap: allowOverlap,
+		}
+	}
+
+	existingPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "existing"},
+		Spec:       spec("10.0.0.10-10.0.0.20", false),
+	}
+	supernet := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "supernet"},
+		Spec:       spec("10.0.0.128/25", false),
+	}
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(existingPool, supernet).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "new"},
+		Spec:       spec("10.0.0.30-10.0.0.40", false),
+	}, &webhook)).Error().To(Succeed(), "should allow pools that do not overlap")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "new"},
+		Spec:       spec("10.0.0.15-10.0.0.25", false),
+	}, &webhook)).Error().To(MatchError(ContainSubstring("addresses overlap with InClusterIPPool team-a/existing")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "new"},
+		Spec:       spec("10.0.0.200", false),
+	}, &webhook)).Error().To(MatchError(ContainSubstring("addresses overlap with GlobalInClusterIPPool supernet")))
+
+	warnings, err := testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "new"},
+		Spec:       spec("10.0.0.15-10.0.0.25", true),
+	}, &webhook)
+	g.Expect(err).NotTo(HaveOccurred(), "should allow overlapping pools with allowOverlap")
+	g.Expect(warnings).To(ConsistOf("addresses overlap with InClusterIPPool team-a/existing"))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "team-b",
+			Name:      "my-cluster-supernet",
+			Labels:    map[string]string{v1alpha2.SubPoolOfLabel: "supernet"},
+		},
+		Spec: spec("10.0.0.128/28", false),
+	}, &webhook)).Error().To(Succeed(), "should allow sub-pools to overlap with their parent")
+
+	updatedPool := existingPool.DeepCopy()
+	updatedPool.Spec.Addresses = []string{"10.0.0.10-10.0.0.25"}
+	g.Expect(webhook.ValidateUpdate(ctx, existingPool, updatedPool)).Error().To(Succeed(), "should not detect an overlap of a pool with itself")
+}
+
+func TestUpdatingPoolsThatAlreadyOverlap(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	spec := v1alpha2.InClusterIPPoolSpec{
+		Addresses: []string{"10.0.0.10-10.0.0.20"},
+		Prefix:    24,
+		Gateway:   "10.0.0.1",
+	}
+	// Both pools were created before overlapping pools were rejected.
+	otherPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "other"},
+		Spec:       spec,
+	}
+	oldPool := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "team-b",
+			Name:              "deleting",
+			Finalizers:        []string{"ipam.cluster.x-k8s.io/ProtectPool"},
+			DeletionTimestamp: ptr.To(metav1.Now()),
+		},
+		Spec: spec,
+	}
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(otherPool, oldPool).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	newPool := oldPool.DeepCopy()
+	newPool.Finalizers = nil
+	g.Expect(webhook.ValidateUpdate(ctx, oldPool, newPool)).Error().To(Succeed(), "should allow removing the finalizer of an overlapping pool")
+
+	oldPool = otherPool.DeepCopy()
+	newPool = otherPool.DeepCopy()
+	newPool.Labels = map[string]string{"team": "a"}
+	g.Expect(webhook.ValidateUpdate(ctx, oldPool, newPool)).Error().To(Succeed(), "should allow updating the metadata of an overlapping pool")
+
+	newPool.Spec.Addresses = []string{"10.0.0.10-10.0.0.21"}
+	g.Expect(webhook.ValidateUpdate(ctx, oldPool, newPool)).Error().To(MatchError(ContainSubstring("addresses overlap with InClusterIPPool team-b/deleting")),
+		"should reject changing the addresses of an overlapping pool")
+}
+
+func TestParentPools(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	spec := func(addresses string, parentRef *v1alpha2.PoolReference) v1alpha2.InClusterIPPoolSpec {
+		return v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{addresses},
+			Prefix:    16,
+			Gateway:   "10.0.0.1",
+			ParentRef: parentRef,
+		}
+	}
+	supernetRef := &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: "supernet"}
+
+	supernet := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "supernet"},
+		Spec:       spec("10.0.0.0/16", nil),
+	}
+	site := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "site"},
+		Spec:       spec("10.0.0.0/20", supernetRef),
+	}
+	rack := &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rack"},
+		Spec:       spec("10.0.0.0/24", &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: "site"}),
+	}
+
+	webhook := InClusterIPPool{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(supernet, site, rack).
+			WithIndex(&ipamv1.IPAddress{}, index.IPAddressPoolRefCombinedField, index.IPAddressByCombinedPoolRef).
+			Build(),
+	}
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-site"},
+		Spec:       spec("10.0.16.0/20", supernetRef),
+	}, &webhook)).Error().To(Succeed(), "should allow a child within its parent")
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "server"},
+		Spec:       spec("10.0.0.0/28", &v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "rack"}),
+	}, &webhook)).Error().To(Succeed(), "should allow an InClusterIPPool as parent in the same namespace")
+
+	outsideSpec := spec("10.0.240.0-10.1.0.10", supernetRef)
+	outsideSpec.Prefix = 8
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-site"},
+		Spec:       outsideSpec,
+	}, &webhook)).Error().To(MatchError(ContainSubstring("addresses must be within the addresses of the parent pool")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-site"},
+		Spec:       spec("10.0.8.0/21", supernetRef),
+	}, &webhook)).Error().To(MatchError(ContainSubstring("addresses overlap with sibling GlobalInClusterIPPool site")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-site"},
+		Spec:       spec("10.0.16.0/20", &v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "rack"}),
+	}, &webhook)).Error().To(MatchError(ContainSubstring("the parent of a GlobalInClusterIPPool must be a GlobalInClusterIPPool")))
+
+	g.Expect(testCreate(ctx, &v1alpha2.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "server"},
+		Spec:       spec("10.0.0.0/28", &v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "rack"}),
+	}, &webhook)).Error().To(MatchError(ContainSubstring("spec.parentRef: Not found")))
+
+	cyclicSupernet := supernet.DeepCopy()
+	cyclicSupernet.Spec.ParentRef = &v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: "site"}
+	g.Expect(webhook.ValidateUpdate(ctx, supernet, cyclicSupernet)).Error().To(MatchError(ContainSubstring("parentRef must not form a cycle")))
+
+	shrunkSite := site.DeepCopy()
+	shrunkSite.Spec.Addresses = []string{"10.0.1.0/24"}
+	g.Expect(webhook.ValidateUpdate(ctx, site, shrunkSite)).Error().To(MatchError(ContainSubstring("pool addresses do not contain allocated addresses")))
+
+	g.Expect(webhook.ValidateDelete(ctx, site)).Error().To(MatchError(ContainSubstring("Pool has child pools")))
+	g.Expect(webhook.ValidateDelete(ctx, rack)).Error().To(Succeed())
+}
+
 func runInvalidScenarioTests(t *testing.T, tt invalidScenarioTest, pool types.GenericInClusterPool, webhook InClusterIPPool) {
 	t.Helper()
 	t.Run(tt.testcase, func(t *testing.T) {
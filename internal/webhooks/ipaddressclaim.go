@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/internal/poolutil"
+)
+
+func (webhook *IPAddressClaim) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ipamv1.IPAddressClaim{}).
+		WithDefaulter(webhook).
+		WithValidator(webhook).
+		Complete()
+}
+
+// The IPAddressClaim webhooks are scoped by the matchConditions in
+// config/webhook/ipaddressclaim_webhook_patch.yaml, so that claims of other
+// IPAM providers are admitted while this provider is unavailable.
+// +kubebuilder:webhook:verbs=create,path=/validate-ipam-cluster-x-k8s-io-v1beta1-ipaddressclaim,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,versions=v1beta1,name=validation.ipaddressclaim.ipam.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+// +kubebuilder:webhook:verbs=create,path=/mutate-ipam-cluster-x-k8s-io-v1beta1-ipaddressclaim,mutating=true,failurePolicy=ignore,matchPolicy=Equivalent,groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,versions=v1beta1,name=default.ipaddressclaim.ipam.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// IPAddressClaim implements a validating and defaulting webhook for IPAddressClaims referencing in-cluster pools.
+type IPAddressClaim struct {
+	Client client.Reader
+}
+
+var (
+	_ webhook.CustomDefaulter = &IPAddressClaim{}
+	_ webhook.CustomValidator = &IPAddressClaim{}
+)
+
+// Default fills an empty poolRef with the default pool of the namespace of
+// the claim, or the cluster-wide default pool.
+func (webhook *IPAddressClaim) Default(ctx context.Context, obj runtime.Object) error {
+	claim, ok := obj.(*ipamv1.IPAddressClaim)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an IPAddressClaim but got a %T", obj))
+	}
+
+	if claim.Spec.PoolRef.Kind != "" || claim.Spec.PoolRef.Name != "" {
+		return nil
+	}
+
+	namespace := claim.Namespace
+	if namespace == "" {
+		if req, err := admission.RequestFromContext(ctx); err == nil {
+			namespace = req.Namespace
+		}
+	}
+
+	pool, err := webhook.defaultPool(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if pool != nil {
+		claim.Spec.PoolRef = poolutil.PoolRef(pool)
+	}
+	return nil
+}
+
+// defaultPool returns the default InClusterIPPool of a namespace, or the
+// default GlobalInClusterIPPool if the namespace has none. It returns nil if
+// there is no default pool.
+func (webhook *IPAddressClaim) defaultPool(ctx context.Context, namespace string) (client.Object, error) {
+	namespacedPools := &v1alpha2.InClusterIPPoolList{}
+	if err := webhook.Client.List(ctx, namespacedPools, client.InNamespace(namespace)); err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	var defaults []client.Object
+	for i := range namespacedPools.Items {
+		if isDefaultPool(&namespacedPools.Items[i]) {
+			defaults = append(defaults, &namespacedPools.Items[i])
+		}
+	}
+
+	if len(defaults) == 0 {
+		globalPools := &v1alpha2.GlobalInClusterIPPoolList{}
+		if err := webhook.Client.List(ctx, globalPools); err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+		for i := range globalPools.Items {
+			if isDefaultPool(&globalPools.Items[i]) {
+				defaults = append(defaults, &globalPools.Items[i])
+			}
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		return nil, nil
+	case 1:
+		return defaults[0], nil
+	default:
+		names := make([]string, 0, len(defaults))
+		for _, pool := range defaults {
+			names = append(names, poolName(pool))
+		}
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("multiple %ss are marked as default: %s", poolutil.PoolKind(defaults[0]), strings.Join(names, ", ")))
+	}
+}
+
+func isDefaultPool(pool client.Object) bool {
+	return pool.GetAnnotations()[v1alpha2.DefaultPoolAnnotation] == "true"
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *IPAddressClaim) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	claim, ok := obj.(*ipamv1.IPAddressClaim)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected an IPAddressClaim but got a %T", obj))
+	}
+
+	poolRef := claim.Spec.PoolRef
+	if poolRef.APIGroup == nil || *poolRef.APIGroup != v1alpha2.GroupVersion.Group || poolRef.Kind != "GlobalInClusterIPPool" {
+		return nil, nil
+	}
+
+	pool := &v1alpha2.GlobalInClusterIPPool{}
+	if err := webhook.Client.Get(ctx, client.ObjectKey{Name: poolRef.Name}, pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The claim will not be fulfilled until the pool exists, which is handled by the controller.
+			return nil, nil
+		}
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	allowed, err := poolutil.NamespaceAllowed(ctx, webhook.Client, pool.PoolSpec(), claim.Namespace)
+	if err != nil {
+		return nil, apierrors.NewInternalError(err)
+	}
+	if !allowed {
+		return nil, apierrors.NewInvalid(ipamv1.GroupVersion.WithKind("IPAddressClaim").GroupKind(), claim.Name, field.ErrorList{
+			field.Forbidden(field.NewPath("spec", "poolRef"),
+				fmt.Sprintf("namespace %s is not allowed to allocate addresses from GlobalInClusterIPPool %s", claim.Namespace, pool.Name)),
+		})
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *IPAddressClaim) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *IPAddressClaim) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
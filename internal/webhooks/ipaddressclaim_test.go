@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+func TestIPAddressClaimAllowedNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	restrictedPool := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "restricted",
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    24,
+			Gateway:   "10.0.0.1",
+			AllowedNamespaces: &v1alpha2.AllowedNamespaces{
+				Names:    []string{"listed"},
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}},
+			},
+		},
+	}
+	openPool := &v1alpha2.GlobalInClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "open",
+		},
+		Spec: v1alpha2.InClusterIPPoolSpec{
+			Addresses: []string{"10.0.0.10-10.0.0.20"},
+			Prefix:    24,
+			Gateway:   "10.0.0.1",
+		},
+	}
+
+	webhook := IPAddressClaim{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				restrictedPool,
+				openPool,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "listed"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "production", Labels: map[string]string{"tier": "production"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}},
+			).
+			Build(),
+	}
+
+	claim := func(namespace, kind, poolName string) *ipamv1.IPAddressClaim {
+		return &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "claim",
+				Namespace: namespace,
+			},
+			Spec: ipamv1.IPAddressClaimSpec{
+				PoolRef: corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To(v1alpha2.GroupVersion.Group),
+					Kind:     kind,
+					Name:     poolName,
+				},
+			},
+		}
+	}
+
+	g.Expect(webhook.ValidateCreate(ctx, claim("listed", "GlobalInClusterIPPool", "restricted"))).
+		Error().To(Succeed(), "should allow namespaces listed by name")
+	g.Expect(webhook.ValidateCreate(ctx, claim("production", "GlobalInClusterIPPool", "restricted"))).
+		Error().To(Succeed(), "should allow namespaces matching the selector")
+	g.Expect(webhook.ValidateCreate(ctx, claim("tenant", "GlobalInClusterIPPool", "restricted"))).
+		Error().To(MatchError(ContainSubstring("namespace tenant is not allowed to allocate addresses from GlobalInClusterIPPool restricted")))
+	g.Expect(webhook.ValidateCreate(ctx, claim("tenant", "GlobalInClusterIPPool", "open"))).
+		Error().To(Succeed(), "should allow all namespaces when allowedNamespaces is not set")
+	g.Expect(webhook.ValidateCreate(ctx, claim("tenant", "GlobalInClusterIPPool", "missing"))).
+		Error().To(Succeed(), "should allow claims for pools that do not exist yet")
+	g.Expect(webhook.ValidateCreate(ctx, claim("tenant", "InClusterIPPool", "restricted"))).
+		Error().To(Succeed(), "should ignore claims for namespaced pools")
+}
+
+func TestIPAddressClaimDefaultPool(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(v1alpha2.AddToScheme(scheme)).To(Succeed())
+
+	defaultAnnotations := map[string]string{v1alpha2.DefaultPoolAnnotation: "true"}
+	webhook := IPAddressClaim{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				&v1alpha2.GlobalInClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: "global-default", Annotations: defaultAnnotations}},
+				&v1alpha2.GlobalInClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: "global"}},
+				&v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "namespace-default", Annotations: defaultAnnotations}},
+				&v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "other"}},
+				&v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "other"}},
+				&v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "team-c", Name: "first", Annotations: defaultAnnotations}},
+				&v1alpha2.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Namespace: "team-c", Name: "second", Annotations: defaultAnnotations}},
+			).
+			Build(),
+	}
+
+	claim := func(namespace string, poolRef corev1.TypedLocalObjectReference) *ipamv1.IPAddressClaim {
+		return &ipamv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "claim"},
+			Spec:       ipamv1.IPAddressClaimSpec{PoolRef: poolRef},
+		}
+	}
+	poolRef := func(kind, name string) corev1.TypedLocalObjectReference {
+		return corev1.TypedLocalObjectReference{APIGroup: ptr.To(v1alpha2.GroupVersion.Group), Kind: kind, Name: name}
+	}
+
+	c := claim("team-a", corev1.TypedLocalObjectReference{})
+	g.Expect(webhook.Default(ctx, c)).To(Succeed())
+	g.Expect(c.Spec.PoolRef).To(Equal(poolRef("InClusterIPPool", "namespace-default")), "should prefer the default pool of the namespace")
+
+	c = claim("team-b", corev1.TypedLocalObjectReference{})
+	g.Expect(webhook.Default(ctx, c)).To(Succeed())
+	g.Expect(c.Spec.PoolRef).To(Equal(poolRef("GlobalInClusterIPPool", "global-default")), "should fall back to the cluster-wide default pool")
+
+	c = claim("team-a", poolRef("GlobalInClusterIPPool", "global"))
+	g.Expect(webhook.Default(ctx, c)).To(Succeed())
+	g.Expect(c.Spec.PoolRef).To(Equal(poolRef("GlobalInClusterIPPool", "global")), "should not change a set poolRef")
+
+	g.Expect(webhook.Default(ctx, claim("team-c", corev1.TypedLocalObjectReference{}))).To(
+		MatchError(ContainSubstring("multiple InClusterIPPools are marked as default: team-c/first, team-c/second")))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+func (webhook *PoolMigration) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha2.PoolMigration{}).
+		WithValidator(webhook).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-ipam-cluster-x-k8s-io-v1alpha2-poolmigration,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=ipam.cluster.x-k8s.io,resources=poolmigrations,versions=v1alpha2,name=validation.poolmigration.ipam.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+// PoolMigration implements a validating webhook for PoolMigrations.
+type PoolMigration struct{}
+
+var _ webhook.CustomValidator = &PoolMigration{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *PoolMigration) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	migration, ok := obj.(*v1alpha2.PoolMigration)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a PoolMigration but got a %T", obj))
+	}
+	return nil, webhook.validate(nil, migration)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *PoolMigration) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldMigration, ok := oldObj.(*v1alpha2.PoolMigration)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a PoolMigration but got a %T", oldObj))
+	}
+	newMigration, ok := newObj.(*v1alpha2.PoolMigration)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a PoolMigration but got a %T", newObj))
+	}
+	return nil, webhook.validate(oldMigration, newMigration)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (webhook *PoolMigration) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (webhook *PoolMigration) validate(oldMigration, newMigration *v1alpha2.PoolMigration) (reterr error) {
+	var allErrs field.ErrorList
+	defer func() {
+		if len(allErrs) > 0 {
+			reterr = apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind("PoolMigration").GroupKind(), newMigration.Name, allErrs)
+		}
+	}()
+
+	// Changing the pools would leave the companion claims of the previous
+	// pools behind, so a new PoolMigration has to be created instead.
+	if oldMigration != nil && oldMigration.Spec != newMigration.Spec {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "spec is immutable"))
+		return
+	}
+
+	if newMigration.Spec.From == newMigration.Spec.To {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "to"), newMigration.Spec.To, "pool must be different from the pool migrated from"))
+	}
+	return
+}
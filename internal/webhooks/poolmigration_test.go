@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+)
+
+func TestPoolMigration(t *testing.T) {
+	g := NewWithT(t)
+
+	migration := func(from, to v1alpha2.PoolReference) *v1alpha2.PoolMigration {
+		return &v1alpha2.PoolMigration{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "renumber"},
+			Spec:       v1alpha2.PoolMigrationSpec{From: from, To: to},
+		}
+	}
+	oldPool := v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "old"}
+	newPool := v1alpha2.PoolReference{Kind: "InClusterIPPool", Name: "new"}
+	globalPool := v1alpha2.PoolReference{Kind: "GlobalInClusterIPPool", Name: "old"}
+
+	webhook := PoolMigration{}
+
+	g.Expect(webhook.ValidateCreate(ctx, migration(oldPool, newPool))).
+		Error().To(Succeed())
+	g.Expect(webhook.ValidateCreate(ctx, migration(oldPool, globalPool))).
+		Error().To(Succeed(), "should allow pools of different kinds with the same name")
+	g.Expect(webhook.ValidateCreate(ctx, migration(oldPool, oldPool))).
+		Error().To(MatchError(ContainSubstring("pool must be different from the pool migrated from")))
+
+	g.Expect(webhook.ValidateUpdate(ctx, migration(oldPool, newPool), migration(oldPool, newPool))).
+		Error().To(Succeed())
+	g.Expect(webhook.ValidateUpdate(ctx, migration(oldPool, newPool), migration(oldPool, globalPool))).
+		Error().To(MatchError(ContainSubstring("spec is immutable")))
+}
@@ -20,6 +20,8 @@ package main
 import (
 	"flag"
 	"os"
+	"sync"
+	"time"
 
 	//+kubebuilder:scaffold:imports
 	"k8s.io/apimachinery/pkg/runtime"
@@ -65,6 +67,8 @@ func main() {
 		probeAddr            string
 		watchNamespace       string
 		watchFilter          string
+
+		orphanedAddressGracePeriod time.Duration
 	)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -74,6 +78,10 @@ func main() {
 	flag.StringVar(&watchNamespace, "namespace", "",
 		"Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
 	flag.StringVar(&watchFilter, "watch-filter", "", "")
+	flag.DurationVar(&orphanedAddressGracePeriod, "orphaned-address-grace-period", 0,
+		"Time after which IPAddresses whose IPAddressClaim or Cluster no longer exists are released. "+
+			"The IPAddressClaims of a Cluster that no longer exists are deleted to release their address. "+
+			"If unspecified, orphaned IPAddresses are only reported.")
 	flag.Parse()
 
 	// klog.Background will automatically use the right logger.
@@ -110,6 +118,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The sub-pool lock is shared by the claim and sub-pool controllers, so
+	// that sub-pools are never carved out of addresses being allocated.
+	subPoolLock := &sync.Mutex{}
+
 	if err = (&ipamutil.ClaimReconciler{
 		Client:           mgr.GetClient(),
 		Scheme:           mgr.GetScheme(),
@@ -117,23 +129,52 @@ func main() {
 		Adapter: &controllers.InClusterProviderAdapter{
 			Client:           mgr.GetClient(),
 			WatchFilterValue: watchFilter,
+			APIReader:        mgr.GetAPIReader(),
+			SubPoolLock:      subPoolLock,
 		},
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IPAddressClaim")
 		os.Exit(1)
 	}
 	if err = (&controllers.InClusterIPPoolReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("inclusterippool-controller"),
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InClusterIPPoolReconciler")
 		os.Exit(1)
 	}
 	if err = (&controllers.GlobalInClusterIPPoolReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("globalinclusterippool-controller"),
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GlobalInClusterIPPoolReconciler")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.OrphanedIPAddressReconciler{
+		Client:      mgr.GetClient(),
+		GracePeriod: orphanedAddressGracePeriod,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OrphanedIPAddressReconciler")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ClusterSubPoolReconciler{
+		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
+		SubPoolLock: subPoolLock,
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterSubPoolReconciler")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.PoolMigrationReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(ctx, mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "GlobalInClusterIPPoolReconciler")
+		setupLog.Error(err, "unable to create controller", "controller", "PoolMigrationReconciler")
 		os.Exit(1)
 	}
 
@@ -141,6 +182,14 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "InClusterIPPool")
 		os.Exit(1)
 	}
+	if err := (&webhooks.IPAddressClaim{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "IPAddressClaim")
+		os.Exit(1)
+	}
+	if err := (&webhooks.PoolMigration{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "PoolMigration")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
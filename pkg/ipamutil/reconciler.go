@@ -34,6 +34,9 @@ const (
 	ProtectAddressFinalizer = "ipam.cluster.x-k8s.io/ProtectAddress"
 )
 
+// errAddressNotEnsured aborts the creation or update of an IPAddress when the ClaimHandler returned a result.
+var errAddressNotEnsured = errors.New("address not ensured")
+
 // ClaimReconciler reconciles a IPAddressClaim object using a ProviderAdapter.
 // It can be used to implement custom IPAM providers without worrying about the basic lifecycle, pausing and owner
 // references, which should be the same or very similar for any provider.
@@ -69,12 +72,22 @@ type ProviderAdapter interface {
 type ClaimHandler interface {
 	// FetchPool is called to fetch the pool referenced by the claim. The pool needs to be stored by the handler.
 	FetchPool(ctx context.Context) (client.Object, *ctrl.Result, error)
-	// EnsureAddress is called to make sure that the IPAddress.Spec is correct and the address is allocated.
+	// EnsureAddress is called to make sure that the IPAddress.Spec is correct and the address is allocated. If it
+	// returns a result without an error, the address is not created or updated and the claim is requeued according to
+	// the result, e.g. to delay the allocation.
 	EnsureAddress(ctx context.Context, address *ipamv1.IPAddress) (*ctrl.Result, error)
 	// ReleaseAddress is called to release the ip address that was allocated for the claim.
 	ReleaseAddress() (*ctrl.Result, error)
 }
 
+// AddressEnsuredHandler can be implemented by a ClaimHandler that needs to know whether the IPAddress completed by
+// EnsureAddress was actually created or updated, e.g. to release resources it acquired for the allocation.
+type AddressEnsuredHandler interface {
+	// AddressEnsured is called after the IPAddress has been created or updated, or failed to be. err is nil if the
+	// IPAddress was created or updated successfully.
+	AddressEnsured(ctx context.Context, err error)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClaimReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	if r.Adapter == nil {
@@ -128,17 +141,20 @@ func (r *ClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ct
 	// Check if the owning cluster is paused
 	if _, ok := claim.GetLabels()[clusterv1.ClusterNameLabel]; ok {
 		cluster, err := clusterutil.GetClusterFromMetadata(ctx, r.Client, claim.ObjectMeta)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
+		switch {
+		case apierrors.IsNotFound(err):
+			// Whether such a claim is orphaned is up to the provider, which can delete it to release its address.
+			if claim.ObjectMeta.DeletionTimestamp.IsZero() {
 				log.Info("IPAddressClaim linked to a cluster that is not found, unable to determine cluster's paused state, skipping reconciliation")
 				return ctrl.Result{}, nil
 			}
-
+			// A claim that is being deleted no longer depends on the cluster's paused state, so the address is
+			// released even though the cluster is gone.
+			log.Info("IPAddressClaim linked to a cluster that is not found is being deleted, releasing address")
+		case err != nil:
 			log.Error(err, "error fetching cluster linked to IPAddressClaim")
 			return ctrl.Result{}, err
-		}
-
-		if annotations.IsPaused(cluster, cluster) {
+		case annotations.IsPaused(cluster, cluster):
 			log.Info("IPAddressClaim linked to a cluster that is paused, skipping reconciliation")
 			return ctrl.Result{}, nil
 		}
@@ -203,6 +219,9 @@ func (r *ClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ct
 		if res, err = handler.EnsureAddress(ctx, &address); err != nil {
 			return err
 		}
+		if res != nil {
+			return errAddressNotEnsured
+		}
 
 		if err = ensureIPAddressOwnerReferences(r.Scheme, &address, claim, pool); err != nil {
 			return errors.Wrap(err, "failed to ensure owner references on address")
@@ -212,7 +231,13 @@ func (r *ClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ct
 
 		return nil
 	})
+	if h, ok := handler.(AddressEnsuredHandler); ok {
+		h.AddressEnsured(ctx, err)
+	}
 
+	if errors.Is(err, errAddressNotEnsured) {
+		return unwrapResult(res), nil
+	}
 	if res != nil || err != nil {
 		if err != nil {
 			err = errors.Wrap(err, "failed to create or patch address")
@@ -18,6 +18,7 @@ limitations under the License.
 package types
 
 import (
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
@@ -28,4 +29,6 @@ type GenericInClusterPool interface {
 	client.Object
 	PoolSpec() *v1alpha2.InClusterIPPoolSpec
 	PoolStatus() *v1alpha2.InClusterIPPoolStatus
+	GetConditions() clusterv1.Conditions
+	SetConditions(clusterv1.Conditions)
 }